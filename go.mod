@@ -5,12 +5,14 @@ go 1.23.4
 toolchain go1.24.0
 
 require (
+	filippo.io/age v1.2.1
 	github.com/charmbracelet/bubbles v0.20.0
 	github.com/charmbracelet/bubbletea v1.1.1
 	github.com/charmbracelet/lipgloss v0.13.0
 	github.com/eventials/go-tus v0.0.0-20220610120217-05d0564bb571
 	github.com/schollz/progressbar/v3 v3.13.1
 	github.com/urfave/cli/v2 v2.25.7
+	golang.org/x/term v0.21.0
 )
 
 require (
@@ -34,8 +36,8 @@ require (
 	github.com/stretchr/testify v1.8.4 // indirect
 	github.com/tus/tusd v1.13.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
-	golang.org/x/term v0.12.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 )
@@ -0,0 +1,290 @@
+package cids
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	carV2HeaderSize = 40
+	cborTagCid      = 42
+)
+
+// WriteCAR builds the DAG for the file or folder at root and writes it to w
+// as a CARv1, returning the root CID
+func WriteCAR(root string, files []string, opts Options, w io.Writer) (string, error) {
+	// The header needs the root CID up front, so blocks are spooled to a
+	// temporary file while the DAG is built and copied after the header
+	spool, err := os.CreateTemp("", "pinata-car-*")
+	if err != nil {
+		return "", errors.Join(err, errors.New("failed to create a temporary file"))
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	sw := bufio.NewWriter(spool)
+	seen := make(map[string]bool)
+	rootCid, err := build(root, files, opts, func(cid []byte, data []byte) error {
+		if seen[string(cid)] {
+			return nil
+		}
+		seen[string(cid)] = true
+
+		_, err := sw.Write(binary.AppendUvarint(nil, uint64(len(cid)+len(data))))
+		if err != nil {
+			return err
+		}
+		_, err = sw.Write(cid)
+		if err != nil {
+			return err
+		}
+		_, err = sw.Write(data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	err = sw.Flush()
+	if err != nil {
+		return "", err
+	}
+	_, err = spool.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	bw := bufio.NewWriter(w)
+	header := encodeCARHeader(rootCid)
+	_, err = bw.Write(binary.AppendUvarint(nil, uint64(len(header))))
+	if err != nil {
+		return "", err
+	}
+	_, err = bw.Write(header)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(bw, spool)
+	if err != nil {
+		return "", err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return "", err
+	}
+
+	return Format(rootCid), nil
+}
+
+// ReadCARRoots returns the root CIDs from the header of a CARv1 or CARv2 file
+func ReadCARRoots(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header, err := readCARHeader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	switch header.version {
+	case 1:
+	case 2:
+		// CARv2 wraps a CARv1 payload found at the data offset
+		fixed := make([]byte, carV2HeaderSize)
+		_, err = io.ReadFull(io.NewSectionReader(f, 11, carV2HeaderSize), fixed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CARv2 header: %w", err)
+		}
+		dataOffset := binary.LittleEndian.Uint64(fixed[16:24])
+		_, err = f.Seek(int64(dataOffset), io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		header, err = readCARHeader(bufio.NewReader(f))
+		if err != nil {
+			return nil, err
+		}
+		if header.version != 1 {
+			return nil, fmt.Errorf("unsupported CAR payload version %d", header.version)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported CAR version %d", header.version)
+	}
+
+	if len(header.roots) == 0 {
+		return nil, errors.New("CAR file has no roots")
+	}
+
+	roots := make([]string, len(header.roots))
+	for i, root := range header.roots {
+		roots[i] = Format(root)
+	}
+	return roots, nil
+}
+
+type carHeader struct {
+	version uint64
+	roots   [][]byte
+}
+
+// encodeCARHeader writes the dag-cbor map {"roots": [cid], "version": 1}
+func encodeCARHeader(root []byte) []byte {
+	out := []byte{0xa2}
+	out = appendCBORHead(out, 3, 5)
+	out = append(out, "roots"...)
+	out = appendCBORHead(out, 4, 1)
+	out = appendCBORHead(out, 6, cborTagCid)
+	// CIDs in dag-cbor carry a leading zero byte for the identity multibase
+	out = appendCBORHead(out, 2, uint64(len(root)+1))
+	out = append(out, 0x00)
+	out = append(out, root...)
+	out = appendCBORHead(out, 3, 7)
+	out = append(out, "version"...)
+	out = appendCBORHead(out, 0, 1)
+	return out
+}
+
+func appendCBORHead(out []byte, major byte, value uint64) []byte {
+	major <<= 5
+	switch {
+	case value < 24:
+		return append(out, major|byte(value))
+	case value <= 0xff:
+		return append(out, major|24, byte(value))
+	case value <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(out, major|25), uint16(value))
+	case value <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(out, major|26), uint32(value))
+	default:
+		return binary.BigEndian.AppendUint64(append(out, major|27), value)
+	}
+}
+
+func readCARHeader(r *bufio.Reader) (carHeader, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return carHeader{}, fmt.Errorf("invalid CAR header: %w", err)
+	}
+	if length == 0 || length > 1<<20 {
+		return carHeader{}, errors.New("invalid CAR header length")
+	}
+
+	buf := make([]byte, length)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return carHeader{}, fmt.Errorf("invalid CAR header: %w", err)
+	}
+
+	value, _, err := decodeCBOR(buf)
+	if err != nil {
+		return carHeader{}, fmt.Errorf("invalid CAR header: %w", err)
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return carHeader{}, errors.New("invalid CAR header: expected a map")
+	}
+
+	header := carHeader{}
+	header.version, ok = fields["version"].(uint64)
+	if !ok {
+		return carHeader{}, errors.New("invalid CAR header: missing version")
+	}
+	roots, _ := fields["roots"].([]interface{})
+	for _, root := range roots {
+		cid, ok := root.(cborCid)
+		if !ok || len(cid) < 2 || cid[0] != 0x00 {
+			return carHeader{}, errors.New("invalid CAR header: bad root CID")
+		}
+		header.roots = append(header.roots, cid[1:])
+	}
+
+	return header, nil
+}
+
+type cborCid []byte
+
+// decodeCBOR decodes the subset of CBOR used by CAR headers
+func decodeCBOR(buf []byte) (interface{}, []byte, error) {
+	if len(buf) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	major := buf[0] >> 5
+	info := buf[0] & 0x1f
+	buf = buf[1:]
+
+	var value uint64
+	switch {
+	case info < 24:
+		value = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(buf) < size {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		for _, b := range buf[:size] {
+			value = value<<8 | uint64(b)
+		}
+		buf = buf[size:]
+	default:
+		return nil, nil, errors.New("indefinite length items are not supported")
+	}
+
+	switch major {
+	case 0:
+		return value, buf, nil
+	case 2, 3:
+		if uint64(len(buf)) < value {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		if major == 3 {
+			return string(buf[:value]), buf[value:], nil
+		}
+		return buf[:value], buf[value:], nil
+	case 4:
+		items := make([]interface{}, 0, value)
+		for i := uint64(0); i < value; i++ {
+			item, rest, err := decodeCBOR(buf)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+			buf = rest
+		}
+		return items, buf, nil
+	case 5:
+		fields := make(map[string]interface{}, value)
+		for i := uint64(0); i < value; i++ {
+			key, rest, err := decodeCBOR(buf)
+			if err != nil {
+				return nil, nil, err
+			}
+			item, rest, err := decodeCBOR(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			if k, ok := key.(string); ok {
+				fields[k] = item
+			}
+			buf = rest
+		}
+		return fields, buf, nil
+	case 6:
+		item, rest, err := decodeCBOR(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if b, ok := item.([]byte); ok && value == cborTagCid {
+			return cborCid(b), rest, nil
+		}
+		return item, rest, nil
+	default:
+		return nil, buf, nil
+	}
+}
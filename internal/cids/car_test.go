@@ -0,0 +1,140 @@
+package cids
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestCAR packs a small folder and returns the CAR bytes and root CID
+func writeTestCAR(t *testing.T) ([]byte, string) {
+	root := t.TempDir()
+	files := make([]string, 0)
+	for name, content := range map[string]string{"a.txt": "hello world\n", "b.bin": pattern(600000)} {
+		path := filepath.Join(root, name)
+		err := os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	var buf bytes.Buffer
+	cid, err := WriteCAR(root, files, DefaultOptions(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := Compute(root, files, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cid != want {
+		t.Fatalf("WriteCAR() root = %s, Compute() = %s", cid, want)
+	}
+	return buf.Bytes(), cid
+}
+
+func TestWriteCARRoundTrip(t *testing.T) {
+	car, cid := writeTestCAR(t)
+
+	path := filepath.Join(t.TempDir(), "out.car")
+	err := os.WriteFile(path, car, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := ReadCARRoots(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0] != cid {
+		t.Fatalf("ReadCARRoots() = %v, want [%s]", roots, cid)
+	}
+
+	// Every block after the header must hash to its CID, with no repeats
+	r := bufio.NewReader(bytes.NewReader(car))
+	_, err = readCARHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	foundRoot := false
+	for {
+		length, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := make([]byte, length)
+		_, err = io.ReadFull(r, block)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A CIDv1 here is a version byte, a codec byte and a 34 byte sha256
+		// multihash
+		blockCid, data := block[:36], block[36:]
+		digest := sha256.Sum256(data)
+		if !bytes.Equal(blockCid[4:], digest[:]) {
+			t.Fatalf("block %s does not match its data", Format(blockCid))
+		}
+		if seen[string(blockCid)] {
+			t.Fatalf("block %s written twice", Format(blockCid))
+		}
+		seen[string(blockCid)] = true
+		if Format(blockCid) == cid {
+			foundRoot = true
+		}
+	}
+	if !foundRoot {
+		t.Fatal("root block missing from the CAR")
+	}
+}
+
+func TestReadCARRootsV2(t *testing.T) {
+	car, cid := writeTestCAR(t)
+
+	// A CARv2 file is a fixed pragma, a 40 byte header pointing at the
+	// CARv1 payload, then the payload itself
+	pragma := []byte{0x0a, 0xa1, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x02}
+	header := make([]byte, carV2HeaderSize)
+	dataOffset := uint64(len(pragma) + carV2HeaderSize)
+	binary.LittleEndian.PutUint64(header[16:24], dataOffset)
+	binary.LittleEndian.PutUint64(header[24:32], uint64(len(car)))
+
+	v2 := append(append(pragma, header...), car...)
+	path := filepath.Join(t.TempDir(), "out.car")
+	err := os.WriteFile(path, v2, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := ReadCARRoots(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0] != cid {
+		t.Fatalf("ReadCARRoots() = %v, want [%s]", roots, cid)
+	}
+}
+
+func TestReadCARRootsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.car")
+	err := os.WriteFile(path, []byte("not a car file"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadCARRoots(path)
+	if err == nil {
+		t.Fatal("expected an error for a file that is not a CAR")
+	}
+}
@@ -0,0 +1,412 @@
+package cids
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	DEFAULT_CHUNK_SIZE = 256 * 1024 // Fixed size chunker used by IPFS
+	DEFAULT_MAX_LINKS  = 174        // Links per node in the balanced layout
+
+	// HAMT_SHARDING_SIZE is the estimated folder node size at which IPFS
+	// switches a folder to a HAMT shard, counting each entry as the length
+	// of its name plus its CID
+	HAMT_SHARDING_SIZE = 256 * 1024
+
+	codecRaw   = 0x55
+	codecDagPb = 0x70
+	hashSha256 = 0x12
+
+	unixfsDirectory = 1
+	unixfsFile      = 2
+)
+
+// Options controls how the DAG for a file or folder is built. The zero
+// value of ChunkSize uses DEFAULT_CHUNK_SIZE
+type Options struct {
+	Version   int
+	RawLeaves bool
+	ChunkSize int
+}
+
+// DefaultOptions matches what Pinata uses for uploads: CIDv1 with raw leaves
+func DefaultOptions() Options {
+	return Options{
+		Version:   1,
+		RawLeaves: true,
+		ChunkSize: DEFAULT_CHUNK_SIZE,
+	}
+}
+
+type node struct {
+	cid  []byte
+	size uint64 // size of the block plus everything it links to
+}
+
+type link struct {
+	name string
+	node node
+}
+
+type builder struct {
+	opts Options
+	// onBlock, when set, receives every block as it is created
+	onBlock func(cid []byte, data []byte) error
+}
+
+// Compute returns the CID of the file or folder at root without uploading
+// it. For folders, files lists the paths under root to include, so callers
+// can apply the same filtering they use for the upload itself. Folders in
+// the list are added as empty folders.
+// Folders large enough to need HAMT sharding are not supported
+func Compute(root string, files []string, opts Options) (string, error) {
+	cid, err := build(root, files, opts, nil)
+	if err != nil {
+		return "", err
+	}
+	return Format(cid), nil
+}
+
+func build(root string, files []string, opts Options, onBlock func(cid []byte, data []byte) error) ([]byte, error) {
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = DEFAULT_CHUNK_SIZE
+	}
+	if opts.Version != 0 && opts.Version != 1 {
+		return nil, fmt.Errorf("invalid CID version: %d. Must be either 0 or 1", opts.Version)
+	}
+	if opts.Version == 0 && opts.RawLeaves {
+		return nil, errors.New("raw leaves need CID version 1, use --raw-leaves=false with --cid-version 0")
+	}
+	b := &builder{opts: opts, onBlock: onBlock}
+
+	stats, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var n node
+	if stats.IsDir() {
+		n, err = b.addFolder(root, files)
+	} else {
+		n, err = b.addPath(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return n.cid, nil
+}
+
+// Format encodes binary CID bytes as a string, base58 for CIDv0 and base32
+// for CIDv1
+func Format(cid []byte) string {
+	if len(cid) == 34 && cid[0] == hashSha256 {
+		return base58Encode(cid)
+	}
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(cid))
+}
+
+func (b *builder) addPath(path string) (node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return node{}, err
+	}
+	defer f.Close()
+	return b.addFile(f)
+}
+
+type folderEntry struct {
+	file     string
+	children map[string]*folderEntry
+}
+
+func (b *builder) addFolder(root string, files []string) (node, error) {
+	tree := &folderEntry{children: map[string]*folderEntry{}}
+	for _, f := range files {
+		relPath, err := filepath.Rel(root, f)
+		if err != nil {
+			return node{}, err
+		}
+		parts := strings.Split(filepath.ToSlash(relPath), "/")
+		current := tree
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current.children[part]
+			if !ok {
+				next = &folderEntry{children: map[string]*folderEntry{}}
+				current.children[part] = next
+			}
+			current = next
+		}
+
+		stats, err := os.Stat(f)
+		if err != nil {
+			return node{}, err
+		}
+		if stats.IsDir() {
+			if _, ok := current.children[parts[len(parts)-1]]; !ok {
+				current.children[parts[len(parts)-1]] = &folderEntry{children: map[string]*folderEntry{}}
+			}
+			continue
+		}
+		current.children[parts[len(parts)-1]] = &folderEntry{file: f}
+	}
+	return b.addEntry(tree)
+}
+
+func (b *builder) addEntry(entry *folderEntry) (node, error) {
+	if entry.children == nil {
+		return b.addPath(entry.file)
+	}
+
+	links := make([]link, 0, len(entry.children))
+	for name, child := range entry.children {
+		n, err := b.addEntry(child)
+		if err != nil {
+			return node{}, err
+		}
+		links = append(links, link{name: name, node: n})
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].name < links[j].name })
+
+	estimatedSize := 0
+	for _, l := range links {
+		estimatedSize += len(l.name) + len(l.node.cid)
+	}
+	if estimatedSize >= HAMT_SHARDING_SIZE {
+		return node{}, fmt.Errorf("a folder with %d entries needs HAMT sharding, which is not supported", len(links))
+	}
+
+	return b.putNode(links, unixfsData(unixfsDirectory, nil, nil, 0, false))
+}
+
+// chunker hands out fixed size chunks while keeping one chunk of lookahead
+// so the layout knows when the file is done
+type chunker struct {
+	r    io.Reader
+	size int
+	next []byte
+	err  error
+}
+
+func newChunker(r io.Reader, size int) *chunker {
+	c := &chunker{r: r, size: size}
+	c.next, c.err = c.read()
+	return c
+}
+
+func (c *chunker) read() ([]byte, error) {
+	buf := make([]byte, c.size)
+	n, err := io.ReadFull(c.r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if n == 0 {
+		return nil, err
+	}
+	return buf[:n], err
+}
+
+func (c *chunker) done() bool {
+	return c.next == nil
+}
+
+func (c *chunker) take() ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	chunk := c.next
+	c.next, c.err = c.read()
+	return chunk, nil
+}
+
+// fileNode is a file node that is still collecting children
+type fileNode struct {
+	links      []link
+	blocksizes []uint64
+}
+
+func (b *builder) addFile(r io.Reader) (node, error) {
+	c := newChunker(r, b.opts.ChunkSize)
+	if c.err != nil {
+		return node{}, c.err
+	}
+
+	root, rootSize, err := b.leaf(c)
+	if err != nil {
+		return node{}, err
+	}
+
+	for depth := 1; !c.done(); depth++ {
+		parent := &fileNode{}
+		parent.add(root, rootSize)
+		root, rootSize, err = b.fill(c, parent, depth)
+		if err != nil {
+			return node{}, err
+		}
+	}
+
+	return root, nil
+}
+
+func (f *fileNode) add(child node, fileSize uint64) {
+	f.links = append(f.links, link{node: child})
+	f.blocksizes = append(f.blocksizes, fileSize)
+}
+
+func (b *builder) fill(c *chunker, parent *fileNode, depth int) (node, uint64, error) {
+	if parent == nil {
+		parent = &fileNode{}
+	}
+	for len(parent.links) < DEFAULT_MAX_LINKS && !c.done() {
+		var child node
+		var childSize uint64
+		var err error
+		if depth == 1 {
+			child, childSize, err = b.leaf(c)
+		} else {
+			child, childSize, err = b.fill(c, nil, depth-1)
+		}
+		if err != nil {
+			return node{}, 0, err
+		}
+		parent.add(child, childSize)
+	}
+
+	var fileSize uint64
+	for _, size := range parent.blocksizes {
+		fileSize += size
+	}
+
+	n, err := b.putNode(parent.links, unixfsData(unixfsFile, nil, parent.blocksizes, fileSize, true))
+	return n, fileSize, err
+}
+
+func (b *builder) leaf(c *chunker) (node, uint64, error) {
+	var data []byte
+	if !c.done() {
+		var err error
+		data, err = c.take()
+		if err != nil {
+			return node{}, 0, err
+		}
+	}
+
+	if b.opts.RawLeaves {
+		n, err := b.putBlock(codecRaw, data)
+		return n, uint64(len(data)), err
+	}
+
+	n, err := b.putNode(nil, unixfsData(unixfsFile, data, nil, uint64(len(data)), true))
+	return n, uint64(len(data)), err
+}
+
+func (b *builder) putNode(links []link, data []byte) (node, error) {
+	encoded := encodePBNode(links, data)
+	n, err := b.putBlock(codecDagPb, encoded)
+	if err != nil {
+		return node{}, err
+	}
+	for _, l := range links {
+		n.size += l.node.size
+	}
+	return n, nil
+}
+
+func (b *builder) putBlock(codec uint64, data []byte) (node, error) {
+	digest := sha256.Sum256(data)
+	multihash := append([]byte{hashSha256, sha256.Size}, digest[:]...)
+
+	var cid []byte
+	if b.opts.Version == 0 && codec == codecDagPb {
+		cid = multihash
+	} else {
+		cid = binary.AppendUvarint(nil, 1)
+		cid = binary.AppendUvarint(cid, codec)
+		cid = append(cid, multihash...)
+	}
+
+	if b.onBlock != nil {
+		err := b.onBlock(cid, data)
+		if err != nil {
+			return node{}, err
+		}
+	}
+
+	return node{cid: cid, size: uint64(len(data))}, nil
+}
+
+// encodePBNode writes a dag-pb node. Links are written before data to match
+// the canonical encoding
+func encodePBNode(links []link, data []byte) []byte {
+	out := make([]byte, 0)
+	for _, l := range links {
+		encodedLink := appendBytesField(nil, 1, l.node.cid)
+		encodedLink = appendBytesField(encodedLink, 2, []byte(l.name))
+		encodedLink = appendVarintField(encodedLink, 3, l.node.size)
+		out = appendBytesField(out, 2, encodedLink)
+	}
+	if data != nil {
+		out = appendBytesField(out, 1, data)
+	}
+	return out
+}
+
+func unixfsData(dataType uint64, data []byte, blocksizes []uint64, fileSize uint64, withFileSize bool) []byte {
+	out := appendVarintField(nil, 1, dataType)
+	if len(data) > 0 {
+		out = appendBytesField(out, 2, data)
+	}
+	if withFileSize {
+		out = appendVarintField(out, 3, fileSize)
+	}
+	for _, size := range blocksizes {
+		out = appendVarintField(out, 4, size)
+	}
+	return out
+}
+
+func appendVarintField(out []byte, field uint64, value uint64) []byte {
+	out = binary.AppendUvarint(out, field<<3)
+	return binary.AppendUvarint(out, value)
+}
+
+func appendBytesField(out []byte, field uint64, value []byte) []byte {
+	out = binary.AppendUvarint(out, field<<3|2)
+	out = binary.AppendUvarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(data []byte) string {
+	num := new(big.Int).SetBytes(data)
+	base := big.NewInt(58)
+	mod := new(big.Int)
+
+	out := make([]byte, 0)
+	for num.Sign() > 0 {
+		num.DivMod(num, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,161 @@
+package cids
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pattern returns n bytes of repeatable content for the larger vectors
+func pattern(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte((i*31 + 7) % 251)
+	}
+	return string(b)
+}
+
+// Expected CIDs are what ipfs add, through the boxo importer, produces with
+// the same options
+func TestComputeFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+	}{
+		{"v0 empty file", "", Options{Version: 0}, "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH"},
+		{"v0 hello world", "hello world\n", Options{Version: 0}, "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o"},
+		{"v1 empty file", "", DefaultOptions(), "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+		{"v1 hello world", "hello world", DefaultOptions(), "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e"},
+		{"v1 hello world without raw leaves", "hello world\n", Options{Version: 1}, "bafybeicg2rebjoofv4kbyovkw7af3rpiitvnl6i7ckcywaq6xjcxnc2mby"},
+		// 4 chunks under one file node
+		{"v0 1MiB", pattern(1 << 20), Options{Version: 0}, "QmfP6GjfZnzRi5Lj1AYF4HurNVhebyeRvCdDnd8VVeCP32"},
+		{"v1 1MiB", pattern(1 << 20), DefaultOptions(), "bafybeihui4agg3d5vku5qnjg6luskvvue7twfha5k4uw4mowbowx4i6uke"},
+		// More chunks than DEFAULT_MAX_LINKS, so the layout grows a second level
+		{"v0 two levels", pattern(175*DEFAULT_CHUNK_SIZE + 1000), Options{Version: 0}, "QmeHp1DvbC6d3xMoYbMR9hESJB3ojjgZTcChorMLGokAiC"},
+		{"v1 two levels", pattern(175*DEFAULT_CHUNK_SIZE + 1000), DefaultOptions(), "bafybeia4ljngurgso4pxylwgwezuliwpv4yrs563mbjfgwgjr455v4oghu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file")
+			err := os.WriteFile(path, []byte(tt.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Compute(path, nil, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Compute() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeEmptyFolder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"v0", Options{Version: 0}, "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"},
+		{"v1", DefaultOptions(), "bafybeiczsscdsbs7ffqz55asqdf3smv6klcw3gofszvwlyarci47bgf354"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compute(t.TempDir(), nil, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Compute() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeFolder(t *testing.T) {
+	root := t.TempDir()
+	entries := map[string]string{
+		"a.txt":     "hello world\n",
+		"data.bin":  pattern(300000),
+		"sub/b.txt": "bbb\n",
+	}
+	files := make([]string, 0, len(entries)+1)
+	for name, content := range entries {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	empty := filepath.Join(root, "sub", "empty")
+	err := os.Mkdir(empty, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, empty)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"v0", Options{Version: 0}, "QmdbZvP1jTXbhRsJ7yDf9kvyyT7oQDv71665BSx5AZv4QF"},
+		{"v1", DefaultOptions(), "bafybeig4yoaiwykf5oocw7swptqvossp3hd7z2bektx4lqmc33jl7cwfdy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compute(root, files, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Compute() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeRejectsV0RawLeaves(t *testing.T) {
+	_, err := Compute(t.TempDir(), nil, Options{Version: 0, RawLeaves: true})
+	if err == nil {
+		t.Fatal("expected an error for CIDv0 with raw leaves")
+	}
+}
+
+func TestAddEntryRejectsShardedFolder(t *testing.T) {
+	// Empty folders keep the test from touching the disk, each entry counts
+	// its name plus a 36 byte CIDv1
+	tree := &folderEntry{children: map[string]*folderEntry{}}
+	name := strings.Repeat("a", 200)
+	for i := 0; len(tree.children)*(len(name)+10+36) < HAMT_SHARDING_SIZE; i++ {
+		tree.children[fmt.Sprintf("%s%010d", name, i)] = &folderEntry{children: map[string]*folderEntry{}}
+	}
+
+	b := &builder{opts: DefaultOptions()}
+	_, err := b.addEntry(tree)
+	if err == nil {
+		t.Fatal("expected an error for a folder past the sharding threshold")
+	}
+
+	delete(tree.children, fmt.Sprintf("%s%010d", name, 0))
+	delete(tree.children, fmt.Sprintf("%s%010d", name, 1))
+	_, err = b.addEntry(tree)
+	if err != nil {
+		t.Fatalf("folder under the sharding threshold: %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Size settings saved with SetSizeSetting
+const (
+	ChunkSizeSetting          = "chunk-size"
+	ResumableThresholdSetting = "resumable-threshold"
+)
+
+var sizeUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"K":   1000,
+	"KB":  1000,
+	"KIB": 1024,
+	"M":   1000 * 1000,
+	"MB":  1000 * 1000,
+	"MIB": 1024 * 1024,
+	"G":   1000 * 1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"GIB": 1024 * 1024 * 1024,
+}
+
+// ParseSize parses a byte size such as 512KB, 5MB or 1.5GiB. Units without
+// an i are decimal, matching the sizes the CLI prints
+func ParseSize(value string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(v)
+	}
+
+	number, err := strconv.ParseFloat(v[:i], 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+	unit, ok := sizeUnits[strings.TrimSpace(v[i:])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit in %s. Use B, KB, MB, GB, KiB, MiB or GiB", value)
+	}
+
+	return int64(number * unit), nil
+}
+
+// ParseRate parses a transfer rate such as 5MB/s into bytes per second. Bit
+// rates must be spelled out, as in 40Mbit/s, and are divided by 8. A lowercase
+// b as in 5mb/s or 40Mbps is rejected, since it could mean either. 0 or off
+// means unlimited
+func ParseRate(value string) (int64, error) {
+	v := strings.TrimSpace(value)
+	if strings.EqualFold(v, "off") {
+		return 0, nil
+	}
+
+	lower := strings.ToLower(v)
+	for _, suffix := range []string{"bits/s", "bit/s", "bits", "bit"} {
+		if strings.HasSuffix(lower, suffix) {
+			return parseBitRate(value, v[:len(v)-len(suffix)])
+		}
+	}
+
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "/s"), "ps")
+	if strings.HasSuffix(v, "b") {
+		return 0, fmt.Errorf("ambiguous rate %s, use B for bytes (5MB/s) or bit for bits (40Mbit/s)", value)
+	}
+	return ParseSize(v)
+}
+
+func parseBitRate(value string, prefixed string) (int64, error) {
+	if strings.HasSuffix(strings.ToUpper(prefixed), "B") {
+		return 0, fmt.Errorf("invalid rate: %s", value)
+	}
+	bits, err := ParseSize(prefixed)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate: %s", value)
+	}
+	return bits / 8, nil
+}
+
+// SetLimitRate saves the default upload rate limit to the config file.
+// Passing 0 or off removes the limit
+func SetLimitRate(rate string) error {
+	bytesPerSecond, err := ParseRate(rate)
+	if err != nil {
+		return err
+	}
+
+	if bytesPerSecond == 0 {
+		err = removeSetting("limit-rate")
+		if err != nil {
+			return err
+		}
+		fmt.Println("Upload rate limit removed")
+		return nil
+	}
+
+	err = writeSetting("limit-rate", rate)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Upload rate limit set to %s\n", rate)
+	return nil
+}
+
+// GetLimitRate retrieves the default upload rate limit from config as it was
+// entered. If none is set, it returns an empty string
+func GetLimitRate() (string, error) {
+	return readSetting("limit-rate")
+}
+
+// GetLimitRateParam returns the upload rate limit in bytes per second from
+// the passed flag value, falling back to the config. 0 means unlimited
+func GetLimitRateParam(rate string) (int64, error) {
+	if rate == "" {
+		saved, err := GetLimitRate()
+		if err != nil {
+			return 0, err
+		}
+		rate = saved
+	}
+	if rate == "" {
+		return 0, nil
+	}
+
+	bytesPerSecond, err := ParseRate(rate)
+	if err != nil {
+		return 0, err
+	}
+	return bytesPerSecond, nil
+}
+
+// SetSizeSetting saves a default byte size such as the TUS chunk size to
+// the config file. Passing 0 or off removes it
+func SetSizeSetting(name string, value string) error {
+	var size int64
+	if !strings.EqualFold(value, "off") {
+		var err error
+		size, err = ParseSize(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if size == 0 {
+		err := removeSetting(name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Default %s removed\n", name)
+		return nil
+	}
+
+	err := writeSetting(name, value)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Default %s set to %s\n", name, value)
+	return nil
+}
+
+// GetSizeSetting retrieves a saved size setting as it was entered. If none
+// is set, it returns an empty string
+func GetSizeSetting(name string) (string, error) {
+	return readSetting(name)
+}
+
+// GetSizeParam returns a size in bytes from the passed flag value, falling
+// back to the saved setting. 0 means use the built in default
+func GetSizeParam(name string, value string) (int64, error) {
+	if value == "" {
+		saved, err := GetSizeSetting(name)
+		if err != nil {
+			return 0, err
+		}
+		value = saved
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	size, err := ParseSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return size, nil
+}
+
+func settingPath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pinata-files-cli-"+name), nil
+}
+
+func readSetting(name string) (string, error) {
+	p, err := settingPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func writeSetting(name string, value string) error {
+	p, err := settingPath(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, []byte(value), 0600)
+}
+
+func removeSetting(name string) error {
+	p, err := settingPath(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"5KB", 5000, false},
+		{"5kb", 5000, false},
+		{"5KiB", 5120, false},
+		{"5MB", 5000000, false},
+		{"1.5GiB", 1610612736, false},
+		{" 50 MiB ", 52428800, false},
+		{"50M", 50000000, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-5MB", 0, true},
+		{"5TB", 0, true},
+		{"five", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSize(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSize(%q) = %d, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSize(%q): %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"off", 0, false},
+		{"OFF", 0, false},
+		{"0", 0, false},
+		{"5MB/s", 5000000, false},
+		{"5MBps", 5000000, false},
+		{"5MB", 5000000, false},
+		{"1KiB/s", 1024, false},
+		{"5MiBps", 5242880, false},
+		{"40Mbit/s", 5000000, false},
+		{"40mbit", 5000000, false},
+		{"40Mbits", 5000000, false},
+		{"1.5Gbit/s", 187500000, false},
+		{"8bit/s", 1, false},
+		{"5mb/s", 0, true},
+		{"5Mb/s", 0, true},
+		{"40Mbps", 0, true},
+		{"8b/s", 0, true},
+		{"5MBbit", 0, true},
+		{"fast", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRate(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRate(%q) = %d, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRate(%q): %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package encryption
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+)
+
+const (
+	// KeyValueEncryption marks an encrypted upload, its value is the format
+	KeyValueEncryption = "encryption"
+	// KeyValueEncryptionMode records how the file key is wrapped, either
+	// ModeX25519 or ModePassphrase
+	KeyValueEncryptionMode = "encryption_mode"
+
+	FormatAge      = "age"
+	ModeX25519     = "x25519"
+	ModePassphrase = "scrypt"
+
+	// PassphraseEnv is read before prompting for a passphrase
+	PassphraseEnv = "PINATA_ENCRYPTION_PASSPHRASE"
+)
+
+// Encryption holds the recipients a file is encrypted to
+type Encryption struct {
+	Recipients []age.Recipient
+	Mode       string
+}
+
+func identityPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pinata-files-cli-age-identity"), nil
+}
+
+// GenerateIdentity creates the X25519 identity used to decrypt downloads
+// and prints its public recipient. An existing identity is never replaced
+func GenerateIdentity() (string, error) {
+	p, err := identityPath()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(p); err == nil {
+		return "", fmt.Errorf("an identity already exists at %s", p)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(p, []byte(identity.String()+"\n"), 0600)
+	if err != nil {
+		return "", err
+	}
+
+	recipient := identity.Recipient().String()
+	fmt.Printf("Identity saved to %s, back it up as files encrypted to it cannot be recovered without it\n", p)
+	fmt.Printf("Public recipient: %s\n", recipient)
+	return recipient, nil
+}
+
+// LoadIdentity reads the identity created by GenerateIdentity
+func LoadIdentity() (*age.X25519Identity, error) {
+	p, err := identityPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no encryption identity found. Create one with 'pinata config encryption keygen'")
+		}
+		return nil, err
+	}
+
+	identity, err := age.ParseX25519Identity(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity in %s: %w", p, err)
+	}
+	return identity, nil
+}
+
+// NewEncryption resolves how uploads are encrypted. With usePassphrase the
+// file key is wrapped with a passphrase, otherwise with the CLI's own
+// identity and any given age recipients, so the uploader can always decrypt
+// what they sent
+func NewEncryption(recipients []string, usePassphrase bool) (*Encryption, error) {
+	if usePassphrase {
+		if len(recipients) > 0 {
+			return nil, errors.New("use either recipients or a passphrase, not both")
+		}
+		passphrase, err := Passphrase(true)
+		if err != nil {
+			return nil, err
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return &Encryption{Recipients: []age.Recipient{recipient}, Mode: ModePassphrase}, nil
+	}
+
+	if len(recipients) == 0 {
+		identity, err := LoadIdentity()
+		if err != nil {
+			return nil, err
+		}
+		return &Encryption{Recipients: []age.Recipient{identity.Recipient()}, Mode: ModeX25519}, nil
+	}
+
+	parsed := make([]age.Recipient, 0, len(recipients)+1)
+	seen := make(map[string]bool)
+	for _, r := range recipients {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %s: %w", r, err)
+		}
+		if seen[recipient.String()] {
+			continue
+		}
+		seen[recipient.String()] = true
+		parsed = append(parsed, recipient)
+	}
+
+	p, err := identityPath()
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(p)
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "Warning: no encryption identity found, only the given recipients will be able to decrypt these files")
+		return &Encryption{Recipients: parsed, Mode: ModeX25519}, nil
+	}
+
+	identity, err := LoadIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if !seen[identity.Recipient().String()] {
+		parsed = append(parsed, identity.Recipient())
+	}
+	return &Encryption{Recipients: parsed, Mode: ModeX25519}, nil
+}
+
+// KeyValues returns the metadata recorded on an encrypted upload
+func (e *Encryption) KeyValues() map[string]string {
+	return map[string]string{
+		KeyValueEncryption:     FormatAge,
+		KeyValueEncryptionMode: e.Mode,
+	}
+}
+
+// Encrypt streams src to dst as an age file
+func (e *Encryption) Encrypt(dst io.Writer, src io.Reader) error {
+	w, err := age.Encrypt(dst, e.Recipients...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Decrypt returns a reader of the plaintext of an age file, using the mode
+// recorded in its keyvalues to pick the CLI identity or a passphrase
+func Decrypt(src io.Reader, mode string) (io.Reader, error) {
+	var identity age.Identity
+	if mode == ModePassphrase {
+		passphrase, err := Passphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		identity, err = age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		x25519, err := LoadIdentity()
+		if err != nil {
+			return nil, err
+		}
+		identity = x25519
+	}
+
+	r, err := age.Decrypt(src, identity)
+	if err != nil {
+		return nil, errors.Join(err, errors.New("failed to decrypt, check the identity or passphrase"))
+	}
+	return r, nil
+}
+
+// IsEncrypted reports whether file keyvalues mark an encrypted upload and
+// returns its mode
+func IsEncrypted(keyvalues map[string]interface{}) (bool, string) {
+	format, _ := keyvalues[KeyValueEncryption].(string)
+	if format != FormatAge {
+		return false, ""
+	}
+	mode, _ := keyvalues[KeyValueEncryptionMode].(string)
+	return true, mode
+}
+
+var cachedPassphrase string
+
+// Passphrase reads the passphrase from PINATA_ENCRYPTION_PASSPHRASE or asks
+// for it on the terminal, once per run
+func Passphrase(confirm bool) (string, error) {
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		cachedPassphrase = p
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to ask for a passphrase, set %s instead", PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("passphrase cannot be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(passphrase) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+
+	cachedPassphrase = string(passphrase)
+	return cachedPassphrase, nil
+}
@@ -0,0 +1,103 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"pinata/internal/config"
+	"pinata/internal/gateways"
+	"strconv"
+	"strings"
+)
+
+// CatFile writes the content of a CID to stdout. The CID can be followed by
+// a path inside a folder, like cid/sub/path. A byte range in the form
+// start-end or start- limits the output, with end included
+func CatFile(cidPath string, byteRange string, network string) error {
+	networkParam, err := config.GetNetworkParam(network)
+	if err != nil {
+		return err
+	}
+
+	start, end, err := parseRange(byteRange)
+	if err != nil {
+		return err
+	}
+
+	url, err := gateways.CreateAccessLink(strings.Trim(cidPath, "/"), 30, networkParam)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to create the request"))
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", "bytes="+byteRange)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if !rangeStartsAt(resp.Header.Get("Content-Range"), start) {
+			return fmt.Errorf("server returned an unexpected range for %s", byteRange)
+		}
+		if end >= 0 {
+			body = io.LimitReader(resp.Body, end-start+1)
+		}
+	case 200:
+		if byteRange != "" {
+			// The gateway ignored the range, so cut it out of the full content
+			_, err = io.CopyN(io.Discard, resp.Body, start)
+			if err != nil {
+				return fmt.Errorf("range %s is past the end of the content", byteRange)
+			}
+			if end >= 0 {
+				body = io.LimitReader(resp.Body, end-start+1)
+			}
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		return fmt.Errorf("range %s is past the end of the content", byteRange)
+	default:
+		return fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	_, err = io.Copy(os.Stdout, body)
+	return err
+}
+
+// parseRange parses start-end or start-. An empty range returns 0 and -1
+func parseRange(byteRange string) (int64, int64, error) {
+	if byteRange == "" {
+		return 0, -1, nil
+	}
+
+	invalid := fmt.Errorf("invalid range %q, use start-end or start-", byteRange)
+	startText, endText, found := strings.Cut(byteRange, "-")
+	if !found || startText == "" {
+		return 0, 0, invalid
+	}
+
+	start, err := strconv.ParseInt(startText, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, invalid
+	}
+	if endText == "" {
+		return start, -1, nil
+	}
+
+	end, err := strconv.ParseInt(endText, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, invalid
+	}
+	return start, end, nil
+}
@@ -0,0 +1,181 @@
+package files
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"pinata/internal/config"
+	"pinata/internal/types"
+	"pinata/internal/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// deleteResult is the outcome of deleting one matched file
+type deleteResult struct {
+	file types.File
+	err  error
+}
+
+// DeleteMatching deletes every file matching the filters, across all pages.
+// The mime filter accepts wildcards like video/*, and createdBefore is a
+// YYYY-MM-DD or RFC3339 date. The number of matches and their total size are
+// shown first, and deleting needs the count typed back or yes to be set.
+// With dryRun the matches are only listed
+func DeleteMatching(group string, keyvalues map[string]string, mime string, createdBefore string, network string, yes bool, dryRun bool, concurrency int) error {
+	if group == "" && len(keyvalues) == 0 && mime == "" && createdBefore == "" {
+		return errors.New("pass a file ID or at least one filter: --group, --kv, --mime or --created-before")
+	}
+	if concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+
+	networkParam, err := config.GetNetworkParam(network)
+	if err != nil {
+		return err
+	}
+
+	before, err := utils.ParseDate(createdBefore, false)
+	if err != nil {
+		return err
+	}
+
+	matches, err := matchFiles(group, keyvalues, mime, before, networkParam)
+	if err != nil {
+		return err
+	}
+
+	totalSize := 0
+	for _, file := range matches {
+		totalSize += file.Size
+	}
+
+	if dryRun {
+		formattedJSON, err := json.MarshalIndent(matches, "", "    ")
+		if err != nil {
+			return errors.New("failed to format JSON")
+		}
+		fmt.Println(string(formattedJSON))
+	}
+
+	fmt.Printf("%d files match, %s in total\n", len(matches), utils.FormatSize(totalSize))
+	if dryRun || len(matches) == 0 {
+		return nil
+	}
+
+	if !yes {
+		err = confirmDelete(len(matches))
+		if err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for result := range deleteFiles(matches, networkParam, concurrency) {
+		if result.err != nil {
+			failed++
+			fmt.Printf("Failed  %s %s: %v\n", result.file.Id, result.file.Name, result.err)
+			continue
+		}
+		fmt.Printf("Deleted %s %s\n", result.file.Id, result.file.Name)
+	}
+
+	fmt.Printf("Deleted %d of %d files\n", len(matches)-failed, len(matches))
+	if failed > 0 {
+		return fmt.Errorf("%d files could not be deleted", failed)
+	}
+
+	return nil
+}
+
+// matchFiles pages through the files in the group and keyvalues, applying
+// the filters the API cannot
+func matchFiles(group string, keyvalues map[string]string, mime string, before time.Time, network string) ([]types.File, error) {
+	// The API only matches exact MIME types, so patterns are checked here
+	serverMime := mime
+	if strings.ContainsAny(mime, "*?[") {
+		serverMime = ""
+	}
+
+	matches := make([]types.File, 0)
+	for file, err := range AllFiles("", "", false, "", "", group, serverMime, keyvalues, network) {
+		if err != nil {
+			return nil, err
+		}
+
+		if serverMime == "" && mime != "" {
+			ok, err := path.Match(mime, file.MimeType)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mime pattern %q", mime)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if !before.IsZero() {
+			created, err := time.Parse(time.RFC3339, file.CreatedAt)
+			if err != nil || !created.Before(before) {
+				continue
+			}
+		}
+
+		matches = append(matches, file)
+	}
+
+	return matches, nil
+}
+
+// confirmDelete asks for the number of files to be typed back
+func confirmDelete(count int) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("no terminal to confirm the delete, pass --yes to delete without confirmation")
+	}
+
+	fmt.Printf("Type %d to delete these files: ", count)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(answer) != strconv.Itoa(count) {
+		return errors.New("confirmation did not match, nothing was deleted")
+	}
+
+	return nil
+}
+
+// deleteFiles deletes the files with a pool of workers, sending each result
+// as soon as it is known. The channel is closed once every file is done
+func deleteFiles(matches []types.File, network string, concurrency int) <-chan deleteResult {
+	jobs := make(chan types.File)
+	results := make(chan deleteResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				results <- deleteResult{file: file, err: removeFile(file.Id, network)}
+			}
+		}()
+	}
+
+	go func() {
+		for _, file := range matches {
+			jobs <- file
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
@@ -0,0 +1,330 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"pinata/internal/config"
+	"pinata/internal/encryption"
+	"pinata/internal/gateways"
+	"pinata/internal/groups"
+	"pinata/internal/types"
+	"strconv"
+	"strings"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// DownloadGroup downloads every file in a group into the output folder,
+// rebuilding the folder layout from the path keyvalue set on upload. With
+// decrypt, files uploaded with --encrypt are decrypted as they are written
+func DownloadGroup(groupId string, output string, network string, decrypt bool) error {
+	networkParam, err := config.GetNetworkParam(network)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		group, err := groups.FetchGroup(groupId, networkParam)
+		if err != nil {
+			return err
+		}
+		// The name comes from the server, so only its last element is used
+		output = filepath.Base(group.Data.Name)
+		if output == "." || output == ".." || output == string(filepath.Separator) {
+			return fmt.Errorf("group name %q cannot be used as a folder name, pass one with --output", group.Data.Name)
+		}
+	}
+
+	count := 0
+	for file, err := range AllFiles("", "", false, "", "", groupId, "", nil, networkParam) {
+		if err != nil {
+			return err
+		}
+
+		relPath := file.Name
+		if path, ok := file.KeyValues[types.PathKeyValue].(string); ok && path != "" {
+			relPath = path
+		}
+
+		dest, err := safeJoin(output, relPath)
+		if err != nil {
+			return err
+		}
+
+		url, err := gateways.CreateAccessLink(file.Cid, 300, networkParam)
+		if err != nil {
+			return err
+		}
+
+		encrypted, mode := encryption.IsEncrypted(file.KeyValues)
+		err = downloadURL(url, dest, file.Cid, decrypt && encrypted, mode, false)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", relPath, err)
+		}
+		fmt.Printf("Downloaded %s\n", dest)
+		count++
+	}
+
+	fmt.Printf("Downloaded %d files to %s\n", count, output)
+
+	return nil
+}
+
+// DownloadFile downloads a single file by CID or file ID. Public content is
+// read through the gateway's /ipfs/ path and private content through a short
+// lived signed link. Without an output path the file's stored name is used,
+// and an output that is a folder gets the file saved inside it
+func DownloadFile(ref string, output string, network string, decrypt bool) error {
+	networkParam, err := config.GetNetworkParam(network)
+	if err != nil {
+		return err
+	}
+
+	file, err := resolveFile(ref, networkParam)
+	if err != nil {
+		return err
+	}
+
+	name := file.Name
+	if name == "" {
+		name = file.Cid
+	}
+
+	dest := output
+	if output == "" {
+		dest, err = safeJoin(".", name)
+	} else if info, statErr := os.Stat(output); statErr == nil && info.IsDir() {
+		dest, err = safeJoin(output, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	url, err := gateways.CreateAccessLink(file.Cid, 300, networkParam)
+	if err != nil {
+		return err
+	}
+
+	encrypted, mode := encryption.IsEncrypted(file.KeyValues)
+	if encrypted && !decrypt {
+		fmt.Fprintf(os.Stderr, "Warning: %s was uploaded encrypted, pass --decrypt to decrypt it\n", name)
+	}
+
+	err = downloadURL(url, dest, file.Cid, decrypt && encrypted, mode, true)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Downloaded %s\n", dest)
+
+	return nil
+}
+
+// resolveFile looks up a file by ID, or by CID when ref is not a file ID.
+// Public content that is not on the account can still be fetched from the
+// gateway, so an unknown public CID resolves to a file with only its CID
+func resolveFile(ref string, network string) (types.File, error) {
+	if isFileId(ref) {
+		response, err := FetchFile(ref, network)
+		if err != nil {
+			return types.File{}, err
+		}
+		return response.Data, nil
+	}
+
+	page, err := FetchFiles("1", "", false, "", ref, "", "", nil, network)
+	if err != nil {
+		return types.File{}, err
+	}
+	if len(page.Data.Files) > 0 {
+		return page.Data.Files[0], nil
+	}
+	if network == config.NetworkPrivate {
+		return types.File{}, fmt.Errorf("no private file found for %s", ref)
+	}
+	return types.File{Cid: ref}, nil
+}
+
+// isFileId reports whether ref is a file ID, which are UUIDs, rather than a
+// CID, which never contains dashes
+func isFileId(ref string) bool {
+	return len(ref) == 36 && strings.Count(ref, "-") == 4
+}
+
+// safeJoin joins a slash separated relative path onto root, refusing paths
+// that would end up outside of it
+func safeJoin(root string, relPath string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(relPath))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to write outside of %s: %s", root, relPath)
+	}
+	return filepath.Join(root, cleaned), nil
+}
+
+// downloadURL saves the content at url to dest, decrypting it first when
+// decrypt is set. The content is written to a .part file next to dest named
+// after the CID, so a download that was interrupted resumes where it stopped
+// with an HTTP Range request and never mixes in bytes of other content. The
+// size is checked against what the server reported before the file is moved
+// into place. A file that fails to decrypt is removed
+func downloadURL(url string, dest string, cid string, decrypt bool, decryptMode string, progress bool) error {
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+
+	partPath := dest + "." + cid + ".part"
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to create the request"))
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := int64(-1)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		if !rangeStartsAt(resp.Header.Get("Content-Range"), offset) {
+			return fmt.Errorf("server returned an unexpected range, remove %s to start over", partPath)
+		}
+		flags |= os.O_APPEND
+		total = contentRangeTotal(resp.Header.Get("Content-Range"))
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file should already hold everything, which the total
+		// in Content-Range confirms
+		total = contentRangeTotal(resp.Header.Get("Content-Range"))
+		if total != offset {
+			return fmt.Errorf("%s does not match the size of the content, remove it to start over", partPath)
+		}
+		return finishDownload(partPath, dest, decrypt, decryptMode)
+	case resp.StatusCode == 200:
+		// The server ignored the range, start over
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+	if total < 0 && resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = f
+	if progress {
+		bar := progressbar.NewOptions64(
+			total,
+			progressbar.OptionEnableColorCodes(true),
+			progressbar.OptionShowBytes(true),
+			progressbar.OptionSetDescription("Downloading..."),
+			progressbar.OptionSetTheme(progressbar.Theme{
+				Saucer:        "█",
+				SaucerPadding: " ",
+				BarStart:      "|",
+				BarEnd:        "|",
+			}),
+			progressbar.OptionOnCompletion(func() {
+				fmt.Println()
+			}),
+		)
+		bar.Set64(offset)
+		w = io.MultiWriter(f, bar)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(err, errors.New("download interrupted, run the command again to resume it"))
+	}
+
+	if total >= 0 {
+		info, err := os.Stat(partPath)
+		if err != nil {
+			return err
+		}
+		if info.Size() < total {
+			return fmt.Errorf("download stopped at %d of %d bytes, run the command again to resume it", info.Size(), total)
+		}
+		if info.Size() > total {
+			os.Remove(partPath)
+			return fmt.Errorf("downloaded %d bytes but expected %d, run the command again to start over", info.Size(), total)
+		}
+	}
+
+	return finishDownload(partPath, dest, decrypt, decryptMode)
+}
+
+// rangeStartsAt reports whether a Content-Range header starts at offset
+func rangeStartsAt(contentRange string, offset int64) bool {
+	return strings.HasPrefix(contentRange, fmt.Sprintf("bytes %d-", offset))
+}
+
+// contentRangeTotal returns the complete length from a Content-Range header
+// such as bytes 0-99/1000 or bytes */1000, or -1 when it is not known
+func contentRangeTotal(contentRange string) int64 {
+	_, totalText, found := strings.Cut(contentRange, "/")
+	if !found {
+		return -1
+	}
+	total, err := strconv.ParseInt(totalText, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
+// finishDownload moves a completed .part file into place, decrypting it on
+// the way when decrypt is set
+func finishDownload(partPath string, dest string, decrypt bool, decryptMode string) error {
+	if !decrypt {
+		return os.Rename(partPath, dest)
+	}
+
+	src, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	body, err := encryption.Decrypt(src, decryptMode)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, body)
+	if err != nil {
+		f.Close()
+		os.Remove(dest)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(partPath)
+}
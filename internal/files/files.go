@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"pinata/internal/common"
 	"pinata/internal/config"
@@ -14,6 +15,19 @@ import (
 )
 
 func DeleteFile(id string, network string) error {
+	err := removeFile(id, network)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("File Deleted")
+
+	return nil
+
+}
+
+// removeFile deletes a file by ID without printing anything
+func removeFile(id string, network string) error {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return err
@@ -43,13 +57,27 @@ func DeleteFile(id string, network string) error {
 		return fmt.Errorf("server Returned an error %d, check ID", resp.StatusCode)
 	}
 
-	fmt.Println("File Deleted")
-
 	return nil
-
 }
 
 func GetFile(id string, network string) (types.GetFileResponse, error) {
+	response, err := FetchFile(id, network)
+	if err != nil {
+		return types.GetFileResponse{}, err
+	}
+	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	if err != nil {
+		return types.GetFileResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+
+}
+
+// FetchFile requests a single file by ID without printing it
+func FetchFile(id string, network string) (types.GetFileResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.GetFileResponse{}, err
@@ -85,15 +113,8 @@ func GetFile(id string, network string) (types.GetFileResponse, error) {
 	if err != nil {
 		return types.GetFileResponse{}, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
-	if err != nil {
-		return types.GetFileResponse{}, errors.New("failed to format JSON")
-	}
-
-	fmt.Println(string(formattedJSON))
 
 	return response, nil
-
 }
 
 func UpdateFile(id string, name string, network string) (types.GetFileResponse, error) {
@@ -153,6 +174,47 @@ func UpdateFile(id string, name string, network string) (types.GetFileResponse,
 }
 
 func ListFiles(amount string, pageToken string, cidPending bool, name string, cid string, group string, mime_type string, keyvalues map[string]string, network string) (types.ListResponse, error) {
+	response, err := FetchFiles(amount, pageToken, cidPending, name, cid, group, mime_type, keyvalues, network)
+	if err != nil {
+		return types.ListResponse{}, err
+	}
+	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	if err != nil {
+		return types.ListResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+
+}
+
+// AllFiles iterates over every file matching the filters, following page
+// tokens from pageToken onwards. Pages are only requested as the loop needs
+// them, so breaking out early stops paging
+func AllFiles(amount string, pageToken string, cidPending bool, name string, cid string, group string, mime_type string, keyvalues map[string]string, network string) iter.Seq2[types.File, error] {
+	return func(yield func(types.File, error) bool) {
+		for {
+			page, err := FetchFiles(amount, pageToken, cidPending, name, cid, group, mime_type, keyvalues, network)
+			if err != nil {
+				yield(types.File{}, err)
+				return
+			}
+			for _, file := range page.Data.Files {
+				if !yield(file, nil) {
+					return
+				}
+			}
+			if page.Data.NextPageToken == "" || page.Data.NextPageToken == pageToken || len(page.Data.Files) == 0 {
+				return
+			}
+			pageToken = page.Data.NextPageToken
+		}
+	}
+}
+
+// FetchFiles requests a single page of files without printing it
+func FetchFiles(amount string, pageToken string, cidPending bool, name string, cid string, group string, mime_type string, keyvalues map[string]string, network string) (types.ListResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.ListResponse{}, err
@@ -229,15 +291,8 @@ func ListFiles(amount string, pageToken string, cidPending bool, name string, ci
 	if err != nil {
 		return types.ListResponse{}, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
-	if err != nil {
-		return types.ListResponse{}, errors.New("failed to format JSON")
-	}
-
-	fmt.Println(string(formattedJSON))
 
 	return response, nil
-
 }
 
 func GetSwapHistory(cid string, domain string, network string) (types.GetSwapHistoryResponse, error) {
@@ -112,30 +112,54 @@ func SetGateway(domain string, useDefault bool) error {
 }
 
 func GetAccessLink(cid string, expires int, network string) (types.GetSignedURLResponse, error) {
+	url, err := CreateAccessLink(cid, expires, network)
+	if err != nil {
+		return types.GetSignedURLResponse{}, err
+	}
+
+	fmt.Println(url)
+
+	return types.GetSignedURLResponse{Data: url}, nil
+}
+
+// GatewayURL returns the unsigned URL of a CID on the saved gateway. Private
+// files can only be read through a signed link from CreateAccessLink
+func GatewayURL(cid string, network string) (string, error) {
+	domain, err := FindGatewayDomain()
+	if err != nil {
+		return "", err
+	}
+
+	if network == config.NetworkPrivate {
+		return fmt.Sprintf("https://%s/files/%s", domain, cid), nil
+	}
+	return fmt.Sprintf("https://%s/ipfs/%s", domain, cid), nil
+}
+
+// CreateAccessLink returns a gateway URL for the CID without printing it.
+// Public files get a plain /ipfs/ link while private files get a signed
+// link that is valid for the given number of seconds
+func CreateAccessLink(cid string, expires int, network string) (string, error) {
 
 	jwt, err := common.FindToken()
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
 	}
 
 	networkParam, err := config.GetNetworkParam(network)
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
 	}
 
-	domain, err := FindGatewayDomain()
+	domainUrl, err := GatewayURL(cid, networkParam)
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
 	}
 
 	if networkParam == "public" {
-		url := fmt.Sprintf("https://%s/ipfs/%s", domain, cid)
-		fmt.Println(url)
-		return types.GetSignedURLResponse{}, err
+		return domainUrl, nil
 	}
 
-	domainUrl := fmt.Sprintf("https://%s/files/%s", domain, cid)
-
 	currentTime := time.Now().Unix()
 
 	payload := types.GetSignedURLBody{
@@ -148,13 +172,13 @@ func GetAccessLink(cid string, expires int, network string) (types.GetSignedURLR
 	jsonPayload, err := json.Marshal(payload)
 
 	if err != nil {
-		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("Failed to marshal paylod"))
+		return "", errors.Join(err, errors.New("Failed to marshal paylod"))
 	}
 
 	url := fmt.Sprintf("https://%s/v3/files/private/download_link", config.GetAPIHost())
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to create the request"))
+		return "", errors.Join(err, errors.New("failed to create the request"))
 	}
 	req.Header.Set("Authorization", "Bearer "+string(jwt))
 	req.Header.Set("content-type", "application/json")
@@ -162,26 +186,24 @@ func GetAccessLink(cid string, expires int, network string) (types.GetSignedURLR
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to send the request"))
+		return "", errors.Join(err, errors.New("failed to send the request"))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return types.GetSignedURLResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+		return "", fmt.Errorf("server Returned an error %d", resp.StatusCode)
 	}
 
 	var response types.GetSignedURLResponse
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
-		return types.GetSignedURLResponse{}, err
+		return "", err
 	}
 
 	unescapedURL := strings.ReplaceAll(response.Data, "\\u0026", "&")
 	unescapedURL = strings.Trim(unescapedURL, "\"")
 
-	fmt.Println(unescapedURL)
-
-	return response, nil
+	return unescapedURL, nil
 }
 
 func OpenCID(cid string, network string) error {
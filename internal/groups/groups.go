@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"pinata/internal/common"
 	"pinata/internal/config"
@@ -13,6 +14,23 @@ import (
 )
 
 func GetGroup(id string, network string) (types.GroupCreateResponse, error) {
+	response, err := FetchGroup(id, network)
+	if err != nil {
+		return types.GroupCreateResponse{}, err
+	}
+	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	if err != nil {
+		return types.GroupCreateResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+
+}
+
+// FetchGroup requests a group by ID without printing it
+func FetchGroup(id string, network string) (types.GroupCreateResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.GroupCreateResponse{}, err
@@ -47,9 +65,18 @@ func GetGroup(id string, network string) (types.GroupCreateResponse, error) {
 	if err != nil {
 		return types.GroupCreateResponse{}, err
 	}
+
+	return response, nil
+}
+
+func ListGroups(amount string, name string, token string, network string) (types.GroupListResponse, error) {
+	response, err := FetchGroups(amount, name, token, network)
+	if err != nil {
+		return types.GroupListResponse{}, err
+	}
 	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
 	if err != nil {
-		return types.GroupCreateResponse{}, errors.New("failed to format JSON")
+		return types.GroupListResponse{}, errors.New("failed to format JSON")
 	}
 
 	fmt.Println(string(formattedJSON))
@@ -58,7 +85,31 @@ func GetGroup(id string, network string) (types.GroupCreateResponse, error) {
 
 }
 
-func ListGroups(amount string, name string, token string, network string) (types.GroupListResponse, error) {
+// AllGroups iterates over every group matching the name, following page
+// tokens from token onwards
+func AllGroups(amount string, name string, token string, network string) iter.Seq2[types.GroupResponseItem, error] {
+	return func(yield func(types.GroupResponseItem, error) bool) {
+		for {
+			page, err := FetchGroups(amount, name, token, network)
+			if err != nil {
+				yield(types.GroupResponseItem{}, err)
+				return
+			}
+			for _, group := range page.Data.Groups {
+				if !yield(group, nil) {
+					return
+				}
+			}
+			if page.Data.NextPageToken == "" || page.Data.NextPageToken == token || len(page.Data.Groups) == 0 {
+				return
+			}
+			token = page.Data.NextPageToken
+		}
+	}
+}
+
+// FetchGroups requests a single page of groups without printing it
+func FetchGroups(amount string, name string, token string, network string) (types.GroupListResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.GroupListResponse{}, err
@@ -112,15 +163,8 @@ func ListGroups(amount string, name string, token string, network string) (types
 	if err != nil {
 		return types.GroupListResponse{}, err
 	}
-	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
-	if err != nil {
-		return types.GroupListResponse{}, errors.New("failed to format JSON")
-	}
-
-	fmt.Println(string(formattedJSON))
 
 	return response, nil
-
 }
 
 func CreateGroup(name string, network string) (types.GroupCreateResponse, error) {
@@ -5,14 +5,66 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"pinata/internal/common"
 	"pinata/internal/config"
 	"pinata/internal/types"
+	"strconv"
 	"strings"
 )
 
 func ListKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset string) (types.KeyListResponse, error) {
+	response, err := FetchKeys(name, revoked, limitedUse, exhausted, offset)
+	if err != nil {
+		return types.KeyListResponse{}, err
+	}
+	formattedJSON, err := json.MarshalIndent(response, "", "    ")
+	if err != nil {
+		return types.KeyListResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+
+}
+
+// AllKeys iterates over every key matching the filters. Keys are paged by
+// offset, so each page starts after the keys already returned
+func AllKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset string) iter.Seq2[types.KeyItem, error] {
+	return func(yield func(types.KeyItem, error) bool) {
+		next := 0
+		if offset != "" {
+			var err error
+			next, err = strconv.Atoi(offset)
+			if err != nil {
+				yield(types.KeyItem{}, fmt.Errorf("invalid offset %q", offset))
+				return
+			}
+		}
+
+		for {
+			page, err := FetchKeys(name, revoked, limitedUse, exhausted, strconv.Itoa(next))
+			if err != nil {
+				yield(types.KeyItem{}, err)
+				return
+			}
+			for _, key := range page.Keys {
+				if !yield(key, nil) {
+					return
+				}
+			}
+			if len(page.Keys) == 0 {
+				return
+			}
+			next += len(page.Keys)
+		}
+	}
+}
+
+// FetchKeys requests a single page of keys without printing it
+func FetchKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset string) (types.KeyListResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.KeyListResponse{}, err
@@ -68,15 +120,8 @@ func ListKeys(name string, revoked bool, limitedUse bool, exhausted bool, offset
 	if err != nil {
 		return types.KeyListResponse{}, err
 	}
-	formattedJSON, err := json.MarshalIndent(response, "", "    ")
-	if err != nil {
-		return types.KeyListResponse{}, errors.New("failed to format JSON")
-	}
-
-	fmt.Println(string(formattedJSON))
 
 	return response, nil
-
 }
 
 func CreateKey(name string, admin bool, uses int, endpoints []string) (types.CreateKeyResponse, error) {
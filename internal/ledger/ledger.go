@@ -0,0 +1,158 @@
+package ledger
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pinata/internal/utils"
+	"strings"
+	"time"
+)
+
+// Entry is a single successful upload recorded in the ledger
+type Entry struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Sha256    string `json:"sha256,omitempty"`
+	Cid       string `json:"cid"`
+	FileId    string `json:"file_id,omitempty"`
+	Network   string `json:"network"`
+	GroupId   string `json:"group_id,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+func ledgerPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pinata-files-cli-history"), nil
+}
+
+// Record appends an entry to the ledger, one JSON object per line
+func Record(entry Entry) error {
+	p, err := ledgerPath()
+	if err != nil {
+		return err
+	}
+
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// HashFile returns the hex encoded SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ListHistory prints ledger entries, newest first, filtered by a substring
+// of the path, an exact CID and a date range. Dates can be given as
+// YYYY-MM-DD or RFC3339, and a limit of 0 returns every match
+func ListHistory(path string, cid string, since string, until string, limit int) ([]Entry, error) {
+	sinceTime, err := utils.ParseDate(since, false)
+	if err != nil {
+		return nil, err
+	}
+	untilTime, err := utils.ParseDate(until, true)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ledgerPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no uploads have been recorded yet")
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			// Skip lines that were only partly written
+			continue
+		}
+
+		if path != "" && !strings.Contains(entry.Path, path) {
+			continue
+		}
+		if cid != "" && entry.Cid != cid {
+			continue
+		}
+		if !sinceTime.IsZero() || !untilTime.IsZero() {
+			timestamp, err := time.Parse(time.RFC3339, entry.Timestamp)
+			if err != nil {
+				continue
+			}
+			if !sinceTime.IsZero() && timestamp.Before(sinceTime) {
+				continue
+			}
+			if !untilTime.IsZero() && !timestamp.Before(untilTime) {
+				continue
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	formattedJSON, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return nil, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return entries, nil
+}
@@ -0,0 +1,229 @@
+package pins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/signal"
+	"pinata/internal/common"
+	"pinata/internal/config"
+	"pinata/internal/files"
+	"pinata/internal/types"
+	"strings"
+	"time"
+)
+
+// Pin job statuses that mean the pin will never complete
+var failedStatuses = map[string]bool{
+	"expired":         true,
+	"over_free_limit": true,
+	"over_max_size":   true,
+	"invalid_object":  true,
+	"bad_host_node":   true,
+}
+
+// PinByHash asks Pinata to fetch content that is already on IPFS and pin it
+// to the public network. The pin is queued and completes in the background
+func PinByHash(cid string, name string, groupId string, keyvalues map[string]string, hostNodes []string) (types.PinByHashResponse, error) {
+	jwt, err := common.FindToken()
+	if err != nil {
+		return types.PinByHashResponse{}, err
+	}
+
+	if name == "" {
+		name = cid
+	}
+
+	payload := types.PinByHashBody{
+		HashToPin: cid,
+		PinataMetadata: types.PinataMetadata{
+			Name:      name,
+			KeyValues: keyvalues,
+		},
+		PinataOptions: types.PinByHashOptions{
+			GroupId:   groupId,
+			HostNodes: hostNodes,
+		},
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return types.PinByHashResponse{}, errors.Join(err, errors.New("failed to marshal paylod"))
+	}
+
+	url := fmt.Sprintf("https://%s/pinning/pinByHash", config.GetAPIHost())
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return types.PinByHashResponse{}, errors.Join(err, errors.New("failed to create the request"))
+	}
+	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.PinByHashResponse{}, errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return types.PinByHashResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	var response types.PinByHashResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return types.PinByHashResponse{}, err
+	}
+	formattedJSON, err := json.MarshalIndent(response, "", "    ")
+	if err != nil {
+		return types.PinByHashResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+}
+
+// ListPinJobs prints the queued pins, optionally filtered by CID and status
+func ListPinJobs(cid string, status string, sort string, limit string, offset string) (types.PinJobsResponse, error) {
+	response, err := FetchPinJobs(cid, status, sort, limit, offset)
+	if err != nil {
+		return types.PinJobsResponse{}, err
+	}
+
+	formattedJSON, err := json.MarshalIndent(response, "", "    ")
+	if err != nil {
+		return types.PinJobsResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+}
+
+// FetchPinJobs requests a page of pin jobs without printing it
+func FetchPinJobs(cid string, status string, sort string, limit string, offset string) (types.PinJobsResponse, error) {
+	jwt, err := common.FindToken()
+	if err != nil {
+		return types.PinJobsResponse{}, err
+	}
+
+	params := neturl.Values{}
+	if cid != "" {
+		params.Set("ipfs_pin_hash", cid)
+	}
+	if status != "" {
+		params.Set("status", status)
+	}
+	if sort != "" {
+		params.Set("sort", strings.ToUpper(sort))
+	}
+	if limit != "" {
+		params.Set("limit", limit)
+	}
+	if offset != "" {
+		params.Set("offset", offset)
+	}
+
+	url := fmt.Sprintf("https://%s/pinning/pinJobs?%s", config.GetAPIHost(), params.Encode())
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return types.PinJobsResponse{}, errors.Join(err, errors.New("failed to create the request"))
+	}
+	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.PinJobsResponse{}, errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return types.PinJobsResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	var response types.PinJobsResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return types.PinJobsResponse{}, err
+	}
+
+	return response, nil
+}
+
+// WaitForPin polls until the pin for a CID completes or fails. A pin job
+// leaves the queue once it is done, so a CID with no job is looked up in the
+// public files. Finding neither is treated as queued, since a new job can
+// take a while to show up. A timeout of 0 waits until interrupted
+func WaitForPin(cid string, interval time.Duration, timeout time.Duration) (types.File, error) {
+	if interval <= 0 {
+		return types.File{}, errors.New("wait interval must be greater than zero")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	lastStatus := ""
+	for {
+		jobs, err := FetchPinJobs(cid, "", "", "", "")
+		if err != nil {
+			return types.File{}, err
+		}
+
+		if len(jobs.Rows) > 0 {
+			job := jobs.Rows[0]
+			if failedStatuses[job.Status] {
+				return types.File{}, fmt.Errorf("pin of %s failed: %s", cid, job.Status)
+			}
+			if job.Status != lastStatus {
+				log.Printf("%s: %s", cid, job.Status)
+				lastStatus = job.Status
+			}
+		} else {
+			page, err := files.FetchFiles("1", "", false, "", cid, "", "", nil, config.NetworkPublic)
+			if err != nil {
+				return types.File{}, err
+			}
+			if len(page.Data.Files) > 0 {
+				file := page.Data.Files[0]
+				formattedJSON, err := json.MarshalIndent(file, "", "    ")
+				if err != nil {
+					return types.File{}, errors.New("failed to format JSON")
+				}
+				fmt.Println(string(formattedJSON))
+				return file, nil
+			}
+
+			if lastStatus != "not found" {
+				log.Printf("%s: no pin job or pinned file yet", cid)
+				lastStatus = "not found"
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return types.File{}, fmt.Errorf("timed out after %s waiting for %s to be pinned", timeout, cid)
+			}
+			return types.File{}, errors.New("stopped waiting, the pin continues in the background")
+		case <-time.After(interval):
+		}
+	}
+}
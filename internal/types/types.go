@@ -14,6 +14,12 @@ type UploadResponse struct {
 		Vectorized    bool              `json:"vectorized"`
 		Network       string            `json:"network,omitempty"`
 		IsDuplicate   bool              `json:"is_duplicate,omitempty"`
+		// NumberOfDuplicates counts the files of a folder upload that the
+		// server reported as already on the network
+		NumberOfDuplicates int `json:"number_of_duplicates,omitempty"`
+		// NumberOfSkipped counts the files of a folder upload that were not
+		// sent because --on-duplicate=skip found them on the network
+		NumberOfSkipped int `json:"number_of_skipped,omitempty"`
 	} `json:"data"`
 }
 
@@ -85,6 +91,17 @@ type GetSignedURLBody struct {
 	Method  string `json:"method"`
 }
 
+type CreateUploadURLBody struct {
+	Network        string            `json:"network"`
+	Date           int64             `json:"date"`
+	Expires        int               `json:"expires"`
+	GroupId        string            `json:"group_id,omitempty"`
+	Filename       string            `json:"filename,omitempty"`
+	KeyValues      map[string]string `json:"keyvalues,omitempty"`
+	MaxFileSize    int64             `json:"max_file_size,omitempty"`
+	AllowMimeTypes []string          `json:"allow_mime_types,omitempty"`
+}
+
 type GetSignedURLResponse struct {
 	Data string `json:"data"`
 }
@@ -183,3 +200,61 @@ type PinataMetadata struct {
 	Name      string            `json:"name"`
 	KeyValues map[string]string `json:"keyvalues,omitempty"`
 }
+
+// PathKeyValue is the keyvalue used to store a file's path relative to the
+// folder it was uploaded from, so the folder can be rebuilt on download
+const PathKeyValue = "path"
+
+type VectorizeResponse struct {
+	Status bool `json:"status"`
+}
+
+type VectorQueryBody struct {
+	Text string `json:"text"`
+}
+
+type VectorQueryMatch struct {
+	FileId string  `json:"file_id"`
+	Cid    string  `json:"cid"`
+	Score  float64 `json:"score"`
+}
+
+type VectorQueryResponse struct {
+	Data struct {
+		Count   int                `json:"count"`
+		Matches []VectorQueryMatch `json:"matches"`
+	} `json:"data"`
+}
+
+type PinByHashOptions struct {
+	GroupId   string   `json:"groupId,omitempty"`
+	HostNodes []string `json:"hostNodes,omitempty"`
+}
+
+type PinByHashBody struct {
+	HashToPin      string           `json:"hashToPin"`
+	PinataMetadata PinataMetadata   `json:"pinataMetadata"`
+	PinataOptions  PinByHashOptions `json:"pinataOptions"`
+}
+
+type PinByHashResponse struct {
+	Id       string `json:"id"`
+	IpfsHash string `json:"ipfsHash"`
+	Status   string `json:"status"`
+	Name     string `json:"name"`
+}
+
+type PinJob struct {
+	Id          string                 `json:"id"`
+	IpfsPinHash string                 `json:"ipfs_pin_hash"`
+	DateQueued  string                 `json:"date_queued"`
+	Name        string                 `json:"name"`
+	Status      string                 `json:"status"`
+	KeyValues   map[string]interface{} `json:"keyvalues"`
+	HostNodes   []string               `json:"host_nodes"`
+}
+
+type PinJobsResponse struct {
+	Count int      `json:"count"`
+	Rows  []PinJob `json:"rows"`
+}
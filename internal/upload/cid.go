@@ -0,0 +1,101 @@
+package uploads
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"pinata/internal/cids"
+	"pinata/internal/types"
+)
+
+// ComputeCID returns the CID the file or folder at filePath will have once
+// uploaded, walking folders with the same filtering as Upload
+func ComputeCID(filePath string, opts Options, cidOpts cids.Options) (string, error) {
+	stats, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := folderPaths(filePath, stats, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return cids.Compute(filePath, files, cidOpts)
+}
+
+// localCID returns the CID expected for an upload, which for CAR files is
+// the root recorded in the file rather than the CID of its bytes
+func localCID(filePath string, opts Options) (string, error) {
+	if opts.Car {
+		roots, err := cids.ReadCARRoots(filePath)
+		if err != nil {
+			return "", err
+		}
+		return roots[0], nil
+	}
+	return ComputeCID(filePath, opts, cids.DefaultOptions())
+}
+
+// PackCAR writes the file or folder at filePath to output as a CAR file,
+// walking folders with the same filtering as Upload
+func PackCAR(filePath string, output string, opts Options, cidOpts cids.Options) (string, error) {
+	stats, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := folderPaths(filePath, stats, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" && output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return cids.WriteCAR(filePath, files, cidOpts, w)
+}
+
+func carUpload(filePath string, stats os.FileInfo, opts Options) (types.UploadResponse, error) {
+	roots, err := cids.ReadCARRoots(filePath)
+	if err != nil {
+		return types.UploadResponse{}, errors.Join(err, errors.New("not a valid CAR file"))
+	}
+	if len(roots) != 1 {
+		return types.UploadResponse{}, fmt.Errorf("CAR file must have exactly one root, found %d", len(roots))
+	}
+
+	response, err := uploadPath(filePath, stats, opts)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+
+	if response.Data.Cid != roots[0] {
+		return response, fmt.Errorf("uploaded CID %s does not match the CAR root %s", response.Data.Cid, roots[0])
+	}
+
+	return response, nil
+}
+
+// verifyUpload checks that the CID Pinata assigned matches the local content
+func verifyUpload(filePath string, opts Options, response types.UploadResponse) error {
+	cid, err := localCID(filePath, opts)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to compute the local CID for verification"))
+	}
+
+	if response.Data.Cid != cid {
+		return fmt.Errorf("verification failed: uploaded CID %s does not match local CID %s", response.Data.Cid, cid)
+	}
+
+	fmt.Printf("Verified %s\n", cid)
+	return nil
+}
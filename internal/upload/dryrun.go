@@ -0,0 +1,157 @@
+package uploads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pinata/internal/cids"
+	"pinata/internal/config"
+)
+
+// CID_UNKNOWN_ENCRYPTED stands in for the CID of encrypted content, which
+// changes with every encryption and is only known once it is uploaded
+const CID_UNKNOWN_ENCRYPTED = "(unknown until encrypted)"
+
+// DryRunFile is a file that would be sent by an upload
+type DryRunFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Method string `json:"method,omitempty"`
+	Cid    string `json:"cid,omitempty"`
+}
+
+// DryRunReport describes everything an upload would do
+type DryRunReport struct {
+	Network       string            `json:"network"`
+	Method        string            `json:"method"`
+	Endpoint      string            `json:"endpoint"`
+	Name          string            `json:"name"`
+	GroupId       string            `json:"group_id,omitempty"`
+	KeyValues     map[string]string `json:"keyvalues,omitempty"`
+	Files         []DryRunFile      `json:"files"`
+	NumberOfFiles int               `json:"number_of_files"`
+	TotalSize     int64             `json:"total_size"`
+	Cid           string            `json:"cid,omitempty"`
+}
+
+// DryRun resolves an upload the same way Upload does and prints what would
+// be sent, without sending anything
+func DryRun(filePath string, opts Options) (DryRunReport, error) {
+	stats, opts, err := checkUpload(filePath, opts)
+	if err != nil {
+		return DryRunReport{}, err
+	}
+
+	report := DryRunReport{
+		Network:   opts.Network,
+		Name:      stats.Name(),
+		GroupId:   opts.GroupId,
+		KeyValues: opts.KeyValues,
+		Files:     make([]DryRunFile, 0),
+	}
+	if opts.Name != "nil" {
+		report.Name = opts.Name
+	}
+
+	privateFolder := stats.IsDir() && opts.Network == config.NetworkPrivate
+	report.Method, report.Endpoint = uploadMethod(stats, opts)
+	if privateFolder && report.GroupId == "" {
+		report.GroupId = fmt.Sprintf("(new group named %s)", report.Name)
+	}
+
+	// The private network uploads each file on its own, so empty folders
+	// are dropped there just like privateFolderUpload does
+	var paths []string
+	if privateFolder {
+		paths, err = pathsFinder(filePath, stats, opts)
+	} else {
+		paths, err = folderPaths(filePath, stats, opts)
+	}
+	if err != nil {
+		return DryRunReport{}, err
+	}
+	if stats.IsDir() && len(paths) == 0 {
+		return DryRunReport{}, errors.New("folder does not contain any files")
+	}
+	if privateFolder && opts.EmptyDirs {
+		warn("empty folders cannot be kept on the private network, each file is uploaded on its own")
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return DryRunReport{}, err
+		}
+
+		file := DryRunFile{Path: filepath.Base(path)}
+		if stats.IsDir() {
+			relPath, err := filepath.Rel(filePath, path)
+			if err != nil {
+				return DryRunReport{}, err
+			}
+			file.Path = filepath.ToSlash(relPath)
+		}
+
+		if info.IsDir() {
+			file.Path += "/"
+		} else {
+			file.Size = info.Size()
+			report.NumberOfFiles++
+		}
+
+		// Private folders are uploaded one file at a time, so each file
+		// gets its own method and CID
+		if privateFolder && !info.IsDir() {
+			file.Method, _ = uploadMethod(info, opts)
+			if opts.Encryption != nil {
+				file.Cid = CID_UNKNOWN_ENCRYPTED
+			} else {
+				file.Cid, err = cids.Compute(path, nil, cids.DefaultOptions())
+				if err != nil {
+					return DryRunReport{}, err
+				}
+			}
+		}
+
+		report.Files = append(report.Files, file)
+		report.TotalSize += file.Size
+	}
+
+	if !privateFolder && opts.Encryption != nil {
+		report.Cid = CID_UNKNOWN_ENCRYPTED
+	} else if !privateFolder {
+		report.Cid, err = localCID(filePath, opts)
+		if err != nil {
+			return DryRunReport{}, errors.Join(err, errors.New("failed to compute the local CID"))
+		}
+	}
+
+	formattedJSON, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return DryRunReport{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return report, nil
+}
+
+// uploadMethod names the code path Upload takes for a path and the endpoint
+// it sends to
+func uploadMethod(stats os.FileInfo, opts Options) (string, string) {
+	uploadsURL := fmt.Sprintf("https://%s/v3/files", config.GetUploadsHost())
+	switch {
+	case stats.IsDir() && opts.Network == config.NetworkPrivate:
+		return "private folder", uploadsURL
+	case stats.IsDir():
+		return "legacy folder", fmt.Sprintf("https://%s/pinning/pinFileToIPFS", config.GetAPIHost())
+	case opts.SignedURL != "":
+		return "signed url", opts.SignedURL
+	case stats.Size() > resumableThreshold(opts):
+		return "tus", uploadsURL
+	default:
+		return "regular", uploadsURL
+	}
+}
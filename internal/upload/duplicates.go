@@ -0,0 +1,127 @@
+package uploads
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"pinata/internal/files"
+	"pinata/internal/types"
+	"strings"
+)
+
+const (
+	DUPLICATE_ALLOW  = "allow"  // Upload anyway, the server decides
+	DUPLICATE_SKIP   = "skip"   // Return the existing file instead
+	DUPLICATE_ERROR  = "error"  // Fail with ErrDuplicate
+	DUPLICATE_RENAME = "rename" // Upload under a new, unused name
+
+	maxRenameAttempts = 1000
+)
+
+// ErrDuplicate is returned when content is already uploaded and the
+// duplicate policy is DUPLICATE_ERROR
+var ErrDuplicate = errors.New("content has already been uploaded")
+
+func validDuplicatePolicy(policy string) bool {
+	switch policy {
+	case "", DUPLICATE_ALLOW, DUPLICATE_SKIP, DUPLICATE_ERROR, DUPLICATE_RENAME:
+		return true
+	}
+	return false
+}
+
+// checkDuplicate applies the duplicate policy before an upload. It returns
+// the response to use instead of uploading when the upload should not
+// happen, and the options to upload with otherwise
+func checkDuplicate(filePath string, stats os.FileInfo, opts Options) (*types.UploadResponse, Options, error) {
+	if opts.OnDuplicate == "" || opts.OnDuplicate == DUPLICATE_ALLOW || opts.Encryption != nil {
+		// Encrypted content never matches what is already uploaded
+		return nil, opts, nil
+	}
+
+	existing, found, err := findExisting(filePath, opts)
+	if err != nil {
+		return nil, opts, err
+	}
+	if !found {
+		return nil, opts, nil
+	}
+
+	switch opts.OnDuplicate {
+	case DUPLICATE_SKIP:
+		response, err := useExisting(existing, opts)
+		if err != nil {
+			return nil, opts, err
+		}
+		fmt.Printf("Skipping upload, %s already exists\n", existing.Cid)
+		if stats.IsDir() {
+			response.Data.NumberOfSkipped = response.Data.NumberOfFiles
+		}
+		err = printResponse(response)
+		if err != nil {
+			return nil, opts, err
+		}
+		return &response, opts, nil
+	case DUPLICATE_ERROR:
+		return nil, opts, fmt.Errorf("%w: %s is already uploaded as %s (%s)", ErrDuplicate, filePath, existing.Name, existing.Cid)
+	default:
+		name := stats.Name()
+		if opts.Name != "nil" {
+			name = opts.Name
+		}
+		opts.Name, err = unusedName(name, opts.Network)
+		if err != nil {
+			return nil, opts, err
+		}
+		fmt.Printf("%s already exists as %s, uploading as %s\n", existing.Cid, existing.Name, opts.Name)
+		return nil, opts, nil
+	}
+}
+
+// unusedName adds a counter to a file name, "report (1).pdf", until no file
+// on the network has that name
+func unusedName(name string, network string) (string, error) {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; i <= maxRenameAttempts; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		// The name filter also returns longer names, so every page is
+		// checked for an exact match
+		taken := false
+		for file, err := range files.AllFiles("", "", false, candidate, "", "", "", nil, network) {
+			if err != nil {
+				return "", err
+			}
+			if file.Name == candidate {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not find an unused name for %s", name)
+}
+
+// findDuplicates checks every file in a folder against the network before
+// anything is uploaded, so DUPLICATE_ERROR fails without a partial upload
+func findDuplicates(root string, paths []string, opts Options) ([]string, error) {
+	duplicates := make([]string, 0)
+	for _, p := range paths {
+		existing, found, err := findExisting(p, opts)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			relPath, err := filepath.Rel(root, p)
+			if err != nil {
+				return nil, err
+			}
+			duplicates = append(duplicates, fmt.Sprintf("%s (%s)", filepath.ToSlash(relPath), existing.Cid))
+		}
+	}
+	return duplicates, nil
+}
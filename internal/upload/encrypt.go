@@ -0,0 +1,69 @@
+package uploads
+
+import (
+	"os"
+	"path/filepath"
+	"pinata/internal/encryption"
+)
+
+// encryptToTemp writes an encrypted copy of a file to a temporary file so
+// the regular and TUS upload paths can send it like any other file. The
+// returned function removes the copy
+func encryptToTemp(filePath string, enc *encryption.Encryption) (string, os.FileInfo, func(), error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer src.Close()
+
+	// The copy keeps the file name with an .age suffix, which is what ends
+	// up in the multipart body
+	dir, err := os.MkdirTemp("", "pinata-encrypted-")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cleanup := func() {
+		os.RemoveAll(dir)
+	}
+
+	dst, err := os.Create(filepath.Join(dir, filepath.Base(filePath)+".age"))
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+
+	err = enc.Encrypt(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+
+	stats, err := os.Stat(dst.Name())
+	if err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+
+	return dst.Name(), stats, cleanup, nil
+}
+
+// encryptedOptions records the encryption parameters in the keyvalues and
+// keeps the original file name, since the upload is sent from a temp file
+func encryptedOptions(opts Options, fileName string) Options {
+	keyvalues := make(map[string]string, len(opts.KeyValues)+2)
+	for key, value := range opts.KeyValues {
+		keyvalues[key] = value
+	}
+	for key, value := range opts.Encryption.KeyValues() {
+		keyvalues[key] = value
+	}
+	opts.KeyValues = keyvalues
+
+	if opts.Name == "nil" {
+		opts.Name = fileName
+	}
+	return opts
+}
@@ -0,0 +1,93 @@
+package uploads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"pinata/internal/files"
+	"pinata/internal/groups"
+	"pinata/internal/types"
+	"strconv"
+)
+
+// findExisting looks for a file on the account with the same CID as the
+// local content
+func findExisting(filePath string, opts Options) (types.File, bool, error) {
+	cid, err := localCID(filePath, opts)
+	if err != nil {
+		return types.File{}, false, errors.Join(err, errors.New("failed to compute the local CID"))
+	}
+
+	page, err := files.FetchFiles("1", "", false, "", cid, "", "", nil, opts.Network)
+	if err != nil {
+		return types.File{}, false, err
+	}
+	if len(page.Data.Files) == 0 {
+		return types.File{}, false, nil
+	}
+
+	return page.Data.Files[0], true, nil
+}
+
+// useExisting returns an existing file in place of an upload. If a group
+// was requested, the existing file is added to that group
+func useExisting(file types.File, opts Options) (types.UploadResponse, error) {
+	if opts.GroupId != "" && (file.GroupId == nil || *file.GroupId != opts.GroupId) {
+		err := groups.AddFile(opts.GroupId, file.Id, opts.Network)
+		if err != nil {
+			return types.UploadResponse{}, err
+		}
+		file.GroupId = &opts.GroupId
+	}
+
+	return responseFromFile(file, opts.Network), nil
+}
+
+// responseFromFile maps a file record onto the upload response format
+func responseFromFile(file types.File, network string) types.UploadResponse {
+	var response types.UploadResponse
+	response.Data.Id = file.Id
+	response.Data.Name = file.Name
+	response.Data.Cid = file.Cid
+	response.Data.Size = file.Size
+	response.Data.CreatedAt = file.CreatedAt
+	response.Data.NumberOfFiles = file.NumberOfFiles
+	response.Data.MimeType = file.MimeType
+	response.Data.GroupId = file.GroupId
+	response.Data.Network = network
+	response.Data.IsDuplicate = true
+
+	if len(file.KeyValues) > 0 {
+		response.Data.KeyValues = make(map[string]string, len(file.KeyValues))
+		for key, value := range file.KeyValues {
+			response.Data.KeyValues[key] = keyValueString(value)
+		}
+	}
+
+	return response
+}
+
+// keyValueString formats a keyvalue decoded from the API. Numbers are written
+// out in full, so 1234567 does not come back as 1.234567e+06
+func keyValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func printResponse(response types.UploadResponse) error {
+	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	if err != nil {
+		return errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+	return nil
+}
@@ -0,0 +1,29 @@
+package uploads
+
+import (
+	"encoding/json"
+	"pinata/internal/types"
+	"testing"
+)
+
+func TestResponseFromFileKeyValues(t *testing.T) {
+	var file types.File
+	err := json.Unmarshal([]byte(`{"id": "1", "keyvalues": {"build": 1234567, "ratio": 0.25, "release": true, "env": "prod"}}`), &file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := responseFromFile(file, "public")
+
+	want := map[string]string{
+		"build":   "1234567",
+		"ratio":   "0.25",
+		"release": "true",
+		"env":     "prod",
+	}
+	for key, value := range want {
+		if response.Data.KeyValues[key] != value {
+			t.Errorf("keyvalue %s = %q, want %q", key, response.Data.KeyValues[key], value)
+		}
+	}
+}
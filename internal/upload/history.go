@@ -0,0 +1,40 @@
+package uploads
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pinata/internal/ledger"
+	"pinata/internal/types"
+)
+
+// recordUpload appends a successful upload to the local ledger. A failure
+// to write the ledger is reported but does not fail the upload
+func recordUpload(filePath string, stats os.FileInfo, response types.UploadResponse, opts Options) {
+	entry := ledger.Entry{
+		Path:    filePath,
+		Size:    int64(response.Data.Size),
+		Cid:     response.Data.Cid,
+		FileId:  response.Data.Id,
+		Network: opts.Network,
+		GroupId: opts.GroupId,
+	}
+
+	if stats != nil {
+		if absPath, err := filepath.Abs(filePath); err == nil {
+			entry.Path = absPath
+		}
+		if !stats.IsDir() {
+			entry.Size = stats.Size()
+			hash, err := ledger.HashFile(filePath)
+			if err == nil {
+				entry.Sha256 = hash
+			}
+		}
+	}
+
+	err := ledger.Record(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record upload in history: %v\n", err)
+	}
+}
@@ -0,0 +1,158 @@
+package uploads
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IGNORE_FILE is read from the root of a folder upload and uses the same
+// pattern syntax as .gitignore
+const IGNORE_FILE = ".pinataignore"
+
+type ignorePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreMatcher matches slash separated paths relative to the upload root.
+// Like .gitignore, the last pattern that matches a path decides the result
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+func loadIgnoreFile(root string) ([]string, error) {
+	f, err := os.Open(filepath.Join(root, IGNORE_FILE))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func newIgnoreMatcher(lines []string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// A slash anywhere but the end ties the pattern to the root,
+		// otherwise it matches a name at any depth
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		p.segments = strings.Split(line, "/")
+		m.patterns = append(m.patterns, p)
+	}
+	return m
+}
+
+// match reports whether relPath is ignored
+func (m *ignoreMatcher) match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.matches(relPath) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// includes reports whether relPath is selected by include patterns. A
+// pattern that matches one of the parent directories selects everything
+// under it, and the deepest match decides, so a negated pattern can drop a
+// subfolder or file from an included directory
+func (m *ignoreMatcher) includes(relPath string) bool {
+	parts := strings.Split(relPath, "/")
+	included := false
+	for i := 1; i <= len(parts); i++ {
+		prefix := strings.Join(parts[:i], "/")
+		isDir := i < len(parts)
+		for _, p := range m.patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.matches(prefix) {
+				included = !p.negate
+			}
+		}
+	}
+	return included
+}
+
+func (p ignorePattern) matches(relPath string) bool {
+	parts := strings.Split(relPath, "/")
+	if !p.anchored {
+		ok, _ := path.Match(p.segments[0], parts[len(parts)-1])
+		return ok
+	}
+	return matchSegments(p.segments, parts)
+}
+
+// matchSegments matches path segments against pattern segments where a
+// "**" segment stands for zero or more directories
+func matchSegments(pattern []string, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// A trailing ** matches what is inside a folder, not the
+			// folder itself
+			if len(pattern) == 1 {
+				return len(parts) > 0
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		ok, _ := path.Match(pattern[0], parts[0])
+		if !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		parts = parts[1:]
+	}
+	return len(parts) == 0
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
@@ -0,0 +1,74 @@
+package uploads
+
+import "testing"
+
+func TestIgnoreMatcherIncludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"dir only pattern", []string{"src/"}, "src/main.go", true},
+		{"dir only pattern nested", []string{"src/"}, "src/pkg/util.go", true},
+		{"dir only pattern skips file", []string{"src/"}, "src", false},
+		{"dir only pattern other dir", []string{"src/"}, "docs/readme.md", false},
+		{"bare dir name", []string{"src"}, "src/main.go", true},
+		{"bare name matches at any depth", []string{"src"}, "lib/src/main.go", true},
+		{"dir star", []string{"docs/*"}, "docs/readme.md", true},
+		{"dir star nested", []string{"docs/*"}, "docs/guide/intro.md", true},
+		{"dir star other dir", []string{"docs/*"}, "src/docs.md", false},
+		{"double star", []string{"**/assets"}, "web/static/assets/logo.png", true},
+		{"double star at root", []string{"**/assets"}, "assets/logo.png", true},
+		{"double star miss", []string{"**/assets"}, "web/static/logo.png", false},
+		{"trailing double star", []string{"docs/**"}, "docs/guide/intro.md", true},
+		{"trailing double star other dir", []string{"docs/**"}, "docsite/index.md", false},
+		{"extension", []string{"*.go"}, "src/main.go", true},
+		{"extension miss", []string{"*.go"}, "src/main.rs", false},
+		{"negated subdir", []string{"src/", "!src/gen/"}, "src/gen/types.go", false},
+		{"negated subdir keeps rest", []string{"src/", "!src/gen/"}, "src/main.go", true},
+		{"negated file", []string{"src/", "!*_test.go"}, "src/main_test.go", false},
+		{"re-included file", []string{"src/", "!src/gen/", "src/gen/keep.go"}, "src/gen/keep.go", true},
+		{"re-included file keeps negation", []string{"src/", "!src/gen/", "src/gen/keep.go"}, "src/gen/drop.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newIgnoreMatcher(tt.patterns)
+			got := m.includes(tt.path)
+			if got != tt.want {
+				t.Errorf("includes(%q) with %v = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"name", []string{"node_modules"}, "web/node_modules", true, true},
+		{"dir only skips file", []string{"build/"}, "build", false, false},
+		{"dir only matches dir", []string{"build/"}, "build", true, true},
+		{"anchored", []string{"/dist"}, "dist", true, true},
+		{"anchored nested miss", []string{"/dist"}, "web/dist", true, false},
+		{"negated", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"trailing double star skips folder", []string{"build/**"}, "build", true, false},
+		{"trailing double star matches contents", []string{"build/**"}, "build/out.js", false, true},
+		{"trailing double star matches nested", []string{"build/**"}, "build/js/out.js", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newIgnoreMatcher(tt.patterns)
+			got := m.match(tt.path, tt.isDir)
+			if got != tt.want {
+				t.Errorf("match(%q, %v) with %v = %v, want %v", tt.path, tt.isDir, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
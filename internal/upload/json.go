@@ -0,0 +1,94 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"pinata/internal/config"
+	"pinata/internal/types"
+	"strings"
+)
+
+// ReadJSONInput returns the JSON document passed as an argument, read from
+// a file, or piped in on stdin when neither is given or the argument is "-"
+func ReadJSONInput(arg string, file string) ([]byte, error) {
+	if arg != "" && file != "" {
+		return nil, errors.New("pass either a JSON string or --file, not both")
+	}
+
+	if file != "" {
+		return os.ReadFile(file)
+	}
+
+	if arg != "" && arg != "-" {
+		return []byte(arg), nil
+	}
+
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if arg == "" && stat.Mode()&os.ModeCharDevice != 0 {
+		return nil, errors.New("no JSON provided, pass a JSON string, --file or pipe it on stdin")
+	}
+
+	return io.ReadAll(os.Stdin)
+}
+
+// UploadJSON validates a JSON document and uploads it as a .json file
+func UploadJSON(data []byte, opts Options) (types.UploadResponse, error) {
+	if !json.Valid(data) {
+		return types.UploadResponse{}, errors.New("input is not valid JSON")
+	}
+
+	fileName := "data.json"
+	if opts.Name != "nil" && opts.Name != "" {
+		fileName = opts.Name
+		if !strings.HasSuffix(strings.ToLower(fileName), ".json") {
+			fileName += ".json"
+		}
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(fileName, `"`, `\"`)))
+	header.Set("Content-Type", "application/json")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+	_, err = part.Write(data)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+
+	err = writeUploadFields(writer, opts, fileName)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+
+	response, err := sendUpload(body, writer.FormDataContentType(), fileName, opts)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err == nil {
+		opts.Network = networkParam
+	}
+	recordUpload(fileName, nil, response, opts)
+
+	return response, nil
+}
@@ -0,0 +1,56 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseKeyValues builds the keyvalues for an upload from an optional JSON file
+// and a list of key=value pairs. Pairs passed on the command line override
+// keys of the same name from the file.
+func ParseKeyValues(pairs []string, file string) (map[string]string, error) {
+	keyvalues := make(map[string]string)
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyvalues file: %w", err)
+		}
+
+		// Numbers are kept as written, so 1234567 is not stored as 1.234567e+06
+		var raw map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+		if err != nil {
+			return nil, fmt.Errorf("keyvalues file must be a JSON object: %w", err)
+		}
+
+		for key, value := range raw {
+			switch v := value.(type) {
+			case string:
+				keyvalues[key] = v
+			case json.Number:
+				keyvalues[key] = v.String()
+			case bool:
+				keyvalues[key] = strconv.FormatBool(v)
+			default:
+				return nil, fmt.Errorf("keyvalue %q must be a string, number or boolean", key)
+			}
+		}
+	}
+
+	for _, kv := range pairs {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid keyvalue %q, expected key=value", kv)
+		}
+		keyvalues[parts[0]] = parts[1]
+	}
+
+	return keyvalues, nil
+}
@@ -0,0 +1,67 @@
+package uploads
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseKeyValuesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyvalues.json")
+	err := os.WriteFile(path, []byte(`{"build": 1234567, "ratio": 0.25, "big": 12345678901234567890, "release": true, "env": "prod"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyvalues, err := ParseKeyValues([]string{"env=staging"}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"build":   "1234567",
+		"ratio":   "0.25",
+		"big":     "12345678901234567890",
+		"release": "true",
+		"env":     "staging",
+	}
+	if len(keyvalues) != len(want) {
+		t.Fatalf("got %d keyvalues, want %d: %v", len(keyvalues), len(want), keyvalues)
+	}
+	for key, value := range want {
+		if keyvalues[key] != value {
+			t.Errorf("keyvalue %s = %q, want %q", key, keyvalues[key], value)
+		}
+	}
+}
+
+func TestParseKeyValuesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		pairs   []string
+		content string
+	}{
+		{"nested object", nil, `{"a": {"b": 1}}`},
+		{"not an object", nil, `[1, 2]`},
+		{"missing equals", []string{"key"}, ""},
+		{"empty key", []string{"=value"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := ""
+			if tt.content != "" {
+				file = filepath.Join(dir, filepath.Base(t.Name())+".json")
+				err := os.WriteFile(file, []byte(tt.content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := ParseKeyValues(tt.pairs, file)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package uploads
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket holding up to one second of transfer.
+// Callers take what they need up front and sleep off any debt, so every
+// reader sharing a limiter gets a fair part of the rate
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+var (
+	limiterMu sync.Mutex
+	limiter   *rateLimiter
+)
+
+// sharedRateLimiter returns the limiter used by every upload in this
+// process, so concurrent uploads split the rate instead of each getting it
+func sharedRateLimiter(rate int64) *rateLimiter {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+
+	if limiter == nil || limiter.rate != rate {
+		limiter = &rateLimiter{rate: rate, tokens: float64(rate), last: time.Now()}
+	}
+	return limiter
+}
+
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	debt := l.tokens
+	l.mu.Unlock()
+
+	if debt < 0 {
+		time.Sleep(time.Duration(-debt / float64(l.rate) * float64(time.Second)))
+	}
+}
+
+type rateLimitedReader struct {
+	r       io.ReadCloser
+	limiter *rateLimiter
+}
+
+func (rr *rateLimitedReader) Read(p []byte) (int, error) {
+	// Small reads keep the transfer smooth instead of sending in bursts
+	if limit := int(rr.limiter.rate / 10); limit > 0 && len(p) > limit {
+		p = p[:limit]
+	}
+	n, err := rr.r.Read(p)
+	if n > 0 {
+		rr.limiter.wait(n)
+	}
+	return n, err
+}
+
+func (rr *rateLimitedReader) Close() error {
+	return rr.r.Close()
+}
+
+// rateLimitedTransport throttles the body of every request it sends
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+	limited := req.Clone(req.Context())
+	limited.Body = &rateLimitedReader{r: req.Body, limiter: t.limiter}
+	return t.base.RoundTrip(limited)
+}
+
+// uploadClient returns the HTTP client for sending upload bodies, throttled
+// when opts.LimitRate is set
+func uploadClient(opts Options) *http.Client {
+	if opts.LimitRate <= 0 {
+		return &http.Client{}
+	}
+	return &http.Client{
+		Transport: &rateLimitedTransport{base: http.DefaultTransport, limiter: sharedRateLimiter(opts.LimitRate)},
+	}
+}
@@ -0,0 +1,163 @@
+package uploads
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"pinata/internal/gateways"
+	"pinata/internal/ledger"
+	"pinata/internal/types"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	RECEIPT_SUFFIX   = ".pinata.json"         // Sidecar written next to an uploaded path
+	RECEIPT_MANIFEST = "receipts.pinata.json" // Manifest written in the receipt folder
+)
+
+// Receipt records where an uploaded path was published
+type Receipt struct {
+	Path       string `json:"path"`
+	Network    string `json:"network"`
+	GatewayURL string `json:"gateway_url,omitempty"`
+	Sha256     string `json:"sha256,omitempty"`
+	types.UploadResponse
+}
+
+// ReceiptManifest holds the receipts of every path uploaded with the same
+// receipt folder, sorted by path
+type ReceiptManifest struct {
+	Receipts []Receipt `json:"receipts"`
+}
+
+var manifestMu sync.Mutex
+
+func writesReceipts(opts Options) bool {
+	return opts.Receipt || opts.ReceiptDir != ""
+}
+
+// writeReceipt saves the receipt for an upload, either as a sidecar next to
+// the uploaded path or merged into the manifest in opts.ReceiptDir
+func writeReceipt(filePath string, stats os.FileInfo, response types.UploadResponse, opts Options) error {
+	if !writesReceipts(opts) {
+		return nil
+	}
+
+	receipt := Receipt{
+		Path:           filepath.Base(filepath.Clean(filePath)),
+		Network:        opts.Network,
+		UploadResponse: response,
+	}
+
+	url, err := gateways.GatewayURL(response.Data.Cid, opts.Network)
+	if err != nil {
+		warn("no gateway saved, leaving the gateway URL out of the receipt")
+	} else {
+		receipt.GatewayURL = url
+	}
+
+	if stats != nil && !stats.IsDir() {
+		hash, err := ledger.HashFile(filePath)
+		if err != nil {
+			return err
+		}
+		receipt.Sha256 = hash
+	}
+
+	if opts.ReceiptDir == "" {
+		return writeJSONFile(filepath.Clean(filePath)+RECEIPT_SUFFIX, receipt)
+	}
+	return addToManifest(filePath, receipt, opts.ReceiptDir)
+}
+
+// addToManifest replaces any receipt for the same path in the manifest, so
+// it always reflects the latest upload of each path. Paths are stored
+// relative to the receipt folder
+func addToManifest(filePath string, receipt Receipt, dir string) error {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+	relPath, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return err
+	}
+	receipt.Path = filepath.ToSlash(relPath)
+
+	manifestPath := filepath.Join(dir, RECEIPT_MANIFEST)
+	var manifest ReceiptManifest
+	data, err := os.ReadFile(manifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &manifest)
+		if err != nil {
+			return errors.Join(err, errors.New("failed to read the receipt manifest "+manifestPath))
+		}
+	}
+
+	replaced := false
+	for i, existing := range manifest.Receipts {
+		if existing.Path == receipt.Path {
+			manifest.Receipts[i] = receipt
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		manifest.Receipts = append(manifest.Receipts, receipt)
+	}
+	sort.Slice(manifest.Receipts, func(i, j int) bool {
+		return manifest.Receipts[i].Path < manifest.Receipts[j].Path
+	})
+
+	return writeJSONFile(manifestPath, manifest)
+}
+
+// writeJSONFile writes through a temporary file so an interrupted run never
+// leaves a truncated receipt behind
+func writeJSONFile(path string, v interface{}) error {
+	formattedJSON, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return errors.New("failed to format JSON")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	err = tmp.Chmod(0644)
+	if err == nil {
+		_, err = tmp.Write(append(formattedJSON, '\n'))
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// isReceipt reports whether a file in a folder upload is a receipt, which is
+// never uploaded alongside the files it describes
+func isReceipt(name string) bool {
+	return strings.HasSuffix(name, RECEIPT_SUFFIX)
+}
@@ -0,0 +1,146 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eventials/go-tus"
+)
+
+const (
+	MAX_CHUNK_RETRIES  = 5               // Attempts per chunk before giving up
+	CHUNK_RETRY_DELAY  = 2 * time.Second // First backoff, doubled on each retry
+	MAX_CHUNK_BACKOFF  = time.Minute     // Upper bound for the backoff
+	chunkOffsetTimeout = 30 * time.Second
+)
+
+// retrySleep waits out the backoff between chunk attempts, tests replace it
+var retrySleep = time.Sleep
+
+// uploadChunks sends the upload at url one chunk at a time from stream. When
+// a chunk fails it waits with exponential backoff, asks the server how much
+// it has received and carries on from there, so a flaky chunk does not
+// restart the upload. Chunks are sent here rather than with a tus.Uploader,
+// which cannot move to a new offset without starting another one
+func uploadChunks(client *tus.Client, url string, stream io.ReadSeeker, size int64, onProgress func(offset int64)) error {
+	var offset int64
+	attempt := 0
+	for offset < size {
+		newOffset, err := uploadChunk(client, url, stream, offset)
+		if err == nil {
+			attempt = 0
+			offset = newOffset
+			if onProgress != nil {
+				onProgress(offset)
+			}
+			continue
+		}
+
+		attempt++
+		if attempt > MAX_CHUNK_RETRIES {
+			return fmt.Errorf("chunk at offset %d failed after %d retries: %w", offset, MAX_CHUNK_RETRIES, err)
+		}
+
+		delay := CHUNK_RETRY_DELAY << (attempt - 1)
+		if delay > MAX_CHUNK_BACKOFF {
+			delay = MAX_CHUNK_BACKOFF
+		}
+		fmt.Fprintf(os.Stderr, "Chunk at offset %d failed: %v. Retrying in %s (%d/%d)\n", offset, err, delay, attempt, MAX_CHUNK_RETRIES)
+		retrySleep(delay)
+
+		serverOffset, err := fetchUploadOffset(client, url)
+		if err != nil {
+			// Retry the same chunk, the next attempt asks again
+			fmt.Fprintf(os.Stderr, "Failed to get the upload offset: %v\n", err)
+			continue
+		}
+
+		offset = serverOffset
+		if onProgress != nil {
+			onProgress(offset)
+		}
+	}
+
+	return nil
+}
+
+// uploadChunk sends one chunk starting at offset and returns the offset the
+// server reports afterwards
+func uploadChunk(client *tus.Client, url string, stream io.ReadSeeker, offset int64) (int64, error) {
+	_, err := stream.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	data := make([]byte, client.Config.ChunkSize)
+	n, err := io.ReadFull(stream, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+
+	method := "PATCH"
+	if client.Config.OverridePatchMethod {
+		method = "POST"
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data[:n]))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	if client.Config.OverridePatchMethod {
+		req.Header.Set("X-HTTP-Method-Override", "PATCH")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 204 {
+		return 0, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	newOffset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Upload-Offset header: %w", err)
+	}
+	if newOffset <= offset {
+		return 0, fmt.Errorf("server did not accept the chunk at offset %d", offset)
+	}
+	return newOffset, nil
+}
+
+// fetchUploadOffset asks the server how many bytes of the upload it has
+func fetchUploadOffset(client *tus.Client, url string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), chunkOffsetTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		return 0, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	offset, err := strconv.ParseInt(resp.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Upload-Offset header: %w", err)
+	}
+	return offset, nil
+}
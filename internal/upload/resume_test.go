@@ -0,0 +1,128 @@
+package uploads
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eventials/go-tus"
+)
+
+// tusServer accepts PATCH requests for one upload, failing the ones that
+// failChunk picks. Failed chunks still store half their bytes, like a
+// connection that drops mid request
+type tusServer struct {
+	mu        sync.Mutex
+	data      []byte
+	patches   int
+	heads     int
+	failChunk func(patch int) bool
+}
+
+func (s *tusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case "HEAD":
+		s.heads++
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.WriteHeader(200)
+	case "PATCH":
+		s.patches++
+		offset, _ := strconv.Atoi(r.Header.Get("Upload-Offset"))
+		if offset != len(s.data) {
+			w.WriteHeader(409)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if s.failChunk(s.patches) {
+			s.data = append(s.data, body[:len(body)/2]...)
+			w.WriteHeader(500)
+			return
+		}
+		s.data = append(s.data, body...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.WriteHeader(204)
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func newTestTUSClient(t *testing.T, url string) *tus.Client {
+	config := tus.DefaultConfig()
+	config.ChunkSize = 10
+	client, err := tus.NewClient(url, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestUploadChunksRetriesFromServerOffset(t *testing.T) {
+	var delays []time.Duration
+	retrySleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { retrySleep = time.Sleep }()
+
+	// The second and third PATCH fail, so the upload has to resume twice
+	// from what the server reports
+	server := &tusServer{failChunk: func(patch int) bool { return patch == 2 || patch == 3 }}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	var progress []int64
+	err := uploadChunks(newTestTUSClient(t, srv.URL), srv.URL+"/files/1", bytes.NewReader(content), int64(len(content)), func(offset int64) {
+		progress = append(progress, offset)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(server.data, content) {
+		t.Errorf("server has %q, want %q", server.data, content)
+	}
+	if server.heads != 2 {
+		t.Errorf("asked for the offset %d times, want 2", server.heads)
+	}
+	wantDelays := []time.Duration{CHUNK_RETRY_DELAY, 2 * CHUNK_RETRY_DELAY}
+	if len(delays) != len(wantDelays) || delays[0] != wantDelays[0] || delays[1] != wantDelays[1] {
+		t.Errorf("backoff delays = %v, want %v", delays, wantDelays)
+	}
+	if progress[len(progress)-1] != int64(len(content)) {
+		t.Errorf("last progress = %d, want %d", progress[len(progress)-1], len(content))
+	}
+}
+
+func TestUploadChunksGivesUp(t *testing.T) {
+	var delays []time.Duration
+	retrySleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { retrySleep = time.Sleep }()
+
+	server := &tusServer{failChunk: func(patch int) bool { return patch > 1 }}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	content := bytes.Repeat([]byte("x"), 1000)
+	err := uploadChunks(newTestTUSClient(t, srv.URL), srv.URL+"/files/1", bytes.NewReader(content), int64(len(content)), nil)
+	if err == nil {
+		t.Fatal("expected the upload to fail")
+	}
+
+	if len(delays) != MAX_CHUNK_RETRIES {
+		t.Errorf("retried %d times, want %d", len(delays), MAX_CHUNK_RETRIES)
+	}
+	if server.patches != MAX_CHUNK_RETRIES+2 {
+		t.Errorf("sent %d chunks, want %d", server.patches, MAX_CHUNK_RETRIES+2)
+	}
+	for i := 1; i < len(delays); i++ {
+		if delays[i] != delays[i-1]*2 && delays[i] != MAX_CHUNK_BACKOFF {
+			t.Errorf("delay %d = %s, want double %s", i, delays[i], delays[i-1])
+		}
+	}
+}
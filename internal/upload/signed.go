@@ -0,0 +1,82 @@
+package uploads
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"pinata/internal/common"
+	"pinata/internal/config"
+	"pinata/internal/types"
+	"strings"
+	"time"
+)
+
+// CreateUploadURL mints a presigned URL that lets someone without the JWT
+// upload a single file, valid for the given number of seconds. The group,
+// name, keyvalues and network in opts are applied to the upload, and
+// maxSize and mimeTypes limit what can be uploaded with it
+func CreateUploadURL(expires int, maxSize int64, mimeTypes []string, opts Options) (types.GetSignedURLResponse, error) {
+	jwt, err := common.FindToken()
+	if err != nil {
+		return types.GetSignedURLResponse{}, err
+	}
+
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err != nil {
+		return types.GetSignedURLResponse{}, err
+	}
+
+	if expires <= 0 {
+		return types.GetSignedURLResponse{}, errors.New("expires must be greater than zero")
+	}
+
+	payload := types.CreateUploadURLBody{
+		Network:        networkParam,
+		Date:           time.Now().Unix(),
+		Expires:        expires,
+		GroupId:        opts.GroupId,
+		KeyValues:      opts.KeyValues,
+		MaxFileSize:    maxSize,
+		AllowMimeTypes: mimeTypes,
+	}
+	if opts.Name != "nil" {
+		payload.Filename = opts.Name
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to marshal payload"))
+	}
+
+	url := fmt.Sprintf("https://%s/v3/files/sign", config.GetUploadsHost())
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to create the request"))
+	}
+	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.GetSignedURLResponse{}, errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return types.GetSignedURLResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	var response types.GetSignedURLResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return types.GetSignedURLResponse{}, err
+	}
+	response.Data = strings.Trim(strings.ReplaceAll(response.Data, "\\u0026", "&"), "\"")
+
+	fmt.Println(response.Data)
+
+	return response, nil
+}
@@ -0,0 +1,169 @@
+package uploads
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pinata/internal/cids"
+	"pinata/internal/config"
+	"pinata/internal/files"
+	"pinata/internal/types"
+	"sort"
+)
+
+type syncUpload struct {
+	path    string
+	relPath string
+	changed bool
+}
+
+type syncDelete struct {
+	id      string
+	relPath string
+}
+
+// Sync makes the files in a group match a local folder. Files are matched
+// on the path keyvalue and compared by CID, so only new or changed files are
+// uploaded. Remote files that no longer exist locally, or were replaced, are
+// deleted when deleteRemoved is set. With dryRun the plan is only printed
+func Sync(dir string, opts Options, deleteRemoved bool, dryRun bool) error {
+	if opts.GroupId == "" {
+		return errors.New("a group ID is required to sync")
+	}
+
+	stats, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !stats.IsDir() {
+		return errors.New("sync requires a folder")
+	}
+
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err != nil {
+		return err
+	}
+	opts.Network = networkParam
+
+	localFiles, err := pathsFinder(dir, stats, opts)
+	if err != nil {
+		return err
+	}
+
+	remote := make(map[string][]types.File)
+	for file, err := range files.AllFiles("", "", false, "", "", opts.GroupId, "", nil, networkParam) {
+		if err != nil {
+			return err
+		}
+		relPath := file.Name
+		if path, ok := file.KeyValues[types.PathKeyValue].(string); ok && path != "" {
+			relPath = path
+		}
+		remote[relPath] = append(remote[relPath], file)
+	}
+
+	toUpload := make([]syncUpload, 0)
+	toDelete := make([]syncDelete, 0)
+	unchanged := 0
+	seen := make(map[string]bool)
+	for _, f := range localFiles {
+		relPath, err := filepath.Rel(dir, f)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		seen[relPath] = true
+
+		cid, err := cids.Compute(f, nil, cids.DefaultOptions())
+		if err != nil {
+			return fmt.Errorf("failed to compute the CID of %s: %w", relPath, err)
+		}
+
+		matched := false
+		for _, file := range remote[relPath] {
+			if file.Cid == cid && !matched {
+				matched = true
+				continue
+			}
+			toDelete = append(toDelete, syncDelete{id: file.Id, relPath: relPath})
+		}
+
+		if matched {
+			unchanged++
+		} else {
+			toUpload = append(toUpload, syncUpload{path: f, relPath: relPath, changed: len(remote[relPath]) > 0})
+		}
+	}
+
+	for relPath, remoteFiles := range remote {
+		if seen[relPath] {
+			continue
+		}
+		for _, file := range remoteFiles {
+			toDelete = append(toDelete, syncDelete{id: file.Id, relPath: relPath})
+		}
+	}
+	sort.Slice(toDelete, func(i, j int) bool { return toDelete[i].relPath < toDelete[j].relPath })
+
+	if dryRun {
+		for _, u := range toUpload {
+			if u.changed {
+				fmt.Printf("upload (changed)  %s\n", u.relPath)
+			} else {
+				fmt.Printf("upload (new)      %s\n", u.relPath)
+			}
+		}
+		for _, d := range toDelete {
+			if deleteRemoved {
+				fmt.Printf("delete            %s (%s)\n", d.relPath, d.id)
+			} else {
+				fmt.Printf("stale             %s (%s)\n", d.relPath, d.id)
+			}
+		}
+		fmt.Printf("%d to upload, %d to delete, %d unchanged\n", len(toUpload), deleteCount(toDelete, deleteRemoved), unchanged)
+		return nil
+	}
+
+	for _, u := range toUpload {
+		fileStats, err := os.Stat(u.path)
+		if err != nil {
+			return err
+		}
+
+		fileOpts := opts
+		fileOpts.Name = u.relPath
+		fileOpts.KeyValues = make(map[string]string, len(opts.KeyValues)+1)
+		for key, value := range opts.KeyValues {
+			fileOpts.KeyValues[key] = value
+		}
+		fileOpts.KeyValues[types.PathKeyValue] = u.relPath
+
+		_, err = uploadPath(u.path, fileStats, fileOpts)
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", u.relPath, err)
+		}
+	}
+
+	if deleteRemoved {
+		for _, d := range toDelete {
+			err = files.DeleteFile(d.id, networkParam)
+			if err != nil {
+				return fmt.Errorf("failed to delete %s: %w", d.relPath, err)
+			}
+		}
+	} else if len(toDelete) > 0 {
+		fmt.Printf("%d remote files are no longer in %s, use --delete to remove them\n", len(toDelete), dir)
+	}
+
+	fmt.Printf("Uploaded %d, deleted %d, unchanged %d\n", len(toUpload), deleteCount(toDelete, deleteRemoved), unchanged)
+
+	return nil
+}
+
+func deleteCount(toDelete []syncDelete, deleteRemoved bool) int {
+	if !deleteRemoved {
+		return 0
+	}
+	return len(toDelete)
+}
@@ -9,15 +9,20 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
+	"path"
 	"path/filepath"
 	"pinata/internal/common"
 	"pinata/internal/config"
+	"pinata/internal/encryption"
 	cliConfig "pinata/internal/config"
+	"pinata/internal/groups"
 	"pinata/internal/types"
+	"pinata/internal/utils"
+	"pinata/internal/vectors"
 	"runtime"
 	"strings"
-	"time"
 
 	"github.com/eventials/go-tus"
 	"github.com/schollz/progressbar/v3"
@@ -28,33 +33,191 @@ const (
 	CHUNK_SIZE              = 50 * 1024 * 1024 + 1  // Chunk size
 )
 
-func Upload(filePath string, groupId string, name string, verbose bool, network string) (types.UploadResponse, error) {
+// Options configures how a file or folder is uploaded
+type Options struct {
+	GroupId   string
+	Name      string
+	Verbose   bool
+	Network   string
+	KeyValues map[string]string
+	// Exclude and Include are gitignore style patterns used to filter folder
+	// uploads, on top of any .pinataignore file at the root of the folder
+	Exclude  []string
+	Include  []string
+	NoHidden bool
+	// OnDuplicate decides what happens when content with the same CID is
+	// already on the network, one of the DUPLICATE_ policies. Empty allows it
+	OnDuplicate string
+	// Car uploads a CAR file as its DAG so the root CID is kept as is
+	Car bool
+	// Verify compares the CID returned by the server with one computed locally
+	Verify bool
+	// ChunkSize is the size of each TUS chunk. 0 uses CHUNK_SIZE
+	ChunkSize int64
+	// ResumableThreshold is the file size above which uploads use TUS. 0
+	// uses MAX_SIZE_REGULAR_UPLOAD
+	ResumableThreshold int64
+	// Vectorize creates embeddings for each uploaded file so it can be
+	// searched with vectors.QueryVectors. Only private files in a group
+	Vectorize bool
+	// Encryption, when set, encrypts each file before it is sent
+	Encryption *encryption.Encryption
+	// SignedURL uploads to a presigned upload URL instead of using the JWT
+	SignedURL string
+	// LimitRate caps upload bandwidth in bytes per second, shared by every
+	// upload in the process. 0 means unlimited
+	LimitRate int64
+	// Symlinks is one of SYMLINKS_FOLLOW, SYMLINKS_SKIP or SYMLINKS_ERROR.
+	// Empty means follow
+	Symlinks string
+	// EmptyDirs keeps empty folders in a folder upload
+	EmptyDirs bool
+	// Receipt writes a RECEIPT_SUFFIX sidecar next to each uploaded path
+	Receipt bool
+	// ReceiptDir collects the receipts in one manifest in this folder
+	// instead of sidecars, and implies Receipt
+	ReceiptDir string
+}
 
-	stats, err := os.Stat(filePath)
+func Upload(filePath string, opts Options) (types.UploadResponse, error) {
+
+	stats, opts, err := checkUpload(filePath, opts)
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
 
-	if stats.IsDir() {
-		// Check if network is private and return error if so
-		networkParam, err := config.GetNetworkParam(network)
+	if opts.Car {
+		return carUpload(filePath, stats, opts)
+	}
+
+	// The private network has no folder support, so each file is uploaded into a group instead
+	if stats.IsDir() && opts.Network == "private" {
+		return privateFolderUpload(filePath, opts)
+	}
+
+	return uploadPath(filePath, stats, opts)
+}
+
+// checkUpload stats the path, resolves the network into opts and rejects
+// option combinations that cannot work before anything is sent
+func checkUpload(filePath string, opts Options) (os.FileInfo, Options, error) {
+	stats, err := os.Stat(filePath)
+	if err != nil {
+		return nil, opts, err
+	}
+
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err != nil {
+		return nil, opts, err
+	}
+	opts.Network = networkParam
+
+	if opts.SignedURL != "" && stats.IsDir() {
+		return nil, opts, errors.New("a signed upload URL can only be used to upload a single file")
+	}
+
+	if opts.Vectorize {
+		if networkParam != config.NetworkPrivate {
+			return nil, opts, errors.New("vectorize is only supported for files on the private network")
+		}
+		if !stats.IsDir() && opts.GroupId == "" {
+			return nil, opts, errors.New("vectorize requires a group, pass one with --group")
+		}
+	}
+
+	if !validDuplicatePolicy(opts.OnDuplicate) {
+		return nil, opts, fmt.Errorf("invalid duplicate policy %q. Must be allow, skip, error or rename", opts.OnDuplicate)
+	}
+
+	if opts.Encryption != nil {
+		if opts.Car || opts.Vectorize {
+			return nil, opts, errors.New("encrypted uploads cannot be combined with --car or --vectorize")
+		}
+		if stats.IsDir() && networkParam != config.NetworkPrivate {
+			return nil, opts, errors.New("encrypted folders are only supported on the private network, where each file is uploaded on its own")
+		}
+	}
+
+	if opts.Car && stats.IsDir() {
+		return nil, opts, errors.New("a CAR upload must be a single .car file")
+	}
+
+	return stats, opts, nil
+}
+
+// uploadPath picks the upload method for a single file or a public folder
+func uploadPath(filePath string, stats os.FileInfo, opts Options) (types.UploadResponse, error) {
+	existing, opts, err := checkDuplicate(filePath, stats, opts)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+	if existing != nil {
+		err = writeReceipt(filePath, stats, *existing, opts)
 		if err != nil {
-			return types.UploadResponse{}, err
+			return *existing, errors.Join(err, errors.New("failed to write the upload receipt"))
 		}
+		return *existing, nil
+	}
 
-		if networkParam == "private" {
-			return types.UploadResponse{}, errors.New("folders are not supported on the private network")
+	sendPath, sendStats := filePath, stats
+	if opts.Encryption != nil {
+		encryptedPath, encryptedStats, cleanup, err := encryptToTemp(filePath, opts.Encryption)
+		if err != nil {
+			return types.UploadResponse{}, errors.Join(err, errors.New("failed to encrypt the file"))
 		}
+		defer cleanup()
+		sendPath, sendStats = encryptedPath, encryptedStats
+		opts = encryptedOptions(opts, stats.Name())
+	}
 
+	var response types.UploadResponse
+	if sendStats.IsDir() {
 		// For folders, we use a different API endpoint
-		return folderUpload(filePath, groupId, name, verbose)
+		response, err = folderUpload(sendPath, opts)
+	} else if sendStats.Size() > resumableThreshold(opts) && opts.SignedURL == "" {
+		response, err = uploadWithTUS(sendPath, sendStats, opts)
+	} else {
+		response, err = regularUpload(sendPath, opts)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	if response.Data.IsDuplicate && opts.OnDuplicate == DUPLICATE_ERROR {
+		return response, fmt.Errorf("%w: the server reported %s as a duplicate", ErrDuplicate, response.Data.Cid)
+	}
+
+	if opts.Verify {
+		err = verifyUpload(sendPath, opts, response)
+		if err != nil {
+			return response, err
+		}
 	}
 
-	if stats.Size() > MAX_SIZE_REGULAR_UPLOAD {
-		return uploadWithTUS(filePath, groupId, name, verbose, stats, network)
+	if opts.Vectorize {
+		_, err = vectors.VectorizeFile(response.Data.Id)
+		if err != nil {
+			return response, errors.Join(err, errors.New("failed to vectorize the file"))
+		}
+		response.Data.Vectorized = true
+		fmt.Printf("Vectorized %s\n", response.Data.Id)
 	}
 
-	return regularUpload(filePath, groupId, name, verbose, network)
+	recordUpload(filePath, stats, response, opts)
+
+	err = writeReceipt(filePath, stats, response, opts)
+	if err != nil {
+		return response, errors.Join(err, errors.New("failed to write the upload receipt"))
+	}
+
+	return response, nil
+}
+
+func resumableThreshold(opts Options) int64 {
+	if opts.ResumableThreshold > 0 {
+		return opts.ResumableThreshold
+	}
+	return MAX_SIZE_REGULAR_UPLOAD
 }
 
 type progressReader struct {
@@ -62,45 +225,59 @@ type progressReader struct {
 	bar *progressbar.ProgressBar
 }
 
-func regularUpload(filePath string, groupId string, name string, verbose bool, network string) (types.UploadResponse, error) {
+func regularUpload(filePath string, opts Options) (types.UploadResponse, error) {
 
-	jwt, err := common.FindToken()
-	if err != nil {
-		return types.UploadResponse{}, err
-	}
 	stats, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
 		fmt.Println("File or folder does not exist")
 		return types.UploadResponse{}, errors.Join(err, errors.New("file or folder does not exist"))
 	}
-	files, err := pathsFinder(filePath, stats)
+	files, err := pathsFinder(filePath, stats, opts)
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
 	body := &bytes.Buffer{}
-	contentType, err := createMultipartRequest(filePath, files, body, stats, groupId, name, network)
+	contentType, err := createMultipartRequest(filePath, files, body, stats, opts)
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
 
+	return sendUpload(body, contentType, stats.Name(), opts)
+}
+
+// sendUpload posts a multipart body to the v3 files endpoint
+func sendUpload(body *bytes.Buffer, contentType string, label string, opts Options) (types.UploadResponse, error) {
+	// A signed upload URL carries its own authorization
+	var jwt []byte
+	url := opts.SignedURL
+	if url == "" {
+		var err error
+		jwt, err = common.FindToken()
+		if err != nil {
+			return types.UploadResponse{}, err
+		}
+		url = fmt.Sprintf("https://%s/v3/files", cliConfig.GetUploadsHost())
+	}
+
 	var requestBody io.Reader
-	if !verbose {
+	if !opts.Verbose {
 		requestBody = body
 	} else {
 		totalSize := int64(body.Len())
-		fmt.Printf("Uploading %s (%s)\n", stats.Name(), formatSize(int(totalSize)))
+		fmt.Printf("Uploading %s (%s)\n", label, utils.FormatSize(int(totalSize)))
 		requestBody = newProgressReader(body, totalSize)
 	}
 
-	url := fmt.Sprintf("https://%s/v3/files", cliConfig.GetUploadsHost())
 	req, err := http.NewRequest("POST", url, requestBody)
 	if err != nil {
 		return types.UploadResponse{}, errors.Join(err, errors.New("failed to create the request"))
 	}
-	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	if jwt != nil {
+		req.Header.Set("Authorization", "Bearer "+string(jwt))
+	}
 	req.Header.Set("content-type", contentType)
 
-	client := &http.Client{}
+	client := uploadClient(opts)
 	resp, err := client.Do(req)
 	if err != nil {
 		return types.UploadResponse{}, errors.Join(err, errors.New("failed to send the request"))
@@ -160,41 +337,23 @@ func (pr *progressReader) Read(p []byte) (n int, err error) {
 	return
 }
 
-func formatSize(bytes int) string {
-	const (
-		KB = 1000
-		MB = KB * KB
-		GB = MB * KB
-	)
-
-	var formattedSize string
-
-	switch {
-	case bytes < KB:
-		formattedSize = fmt.Sprintf("%d bytes", bytes)
-	case bytes < MB:
-		formattedSize = fmt.Sprintf("%.2f KB", float64(bytes)/KB)
-	case bytes < GB:
-		formattedSize = fmt.Sprintf("%.2f MB", float64(bytes)/MB)
-	default:
-		formattedSize = fmt.Sprintf("%.2f GB", float64(bytes)/GB)
-	}
-
-	return formattedSize
-}
-
-func uploadWithTUS(filePath string, groupId string, name string, verbose bool, stats os.FileInfo, network string) (types.UploadResponse, error) {
+func uploadWithTUS(filePath string, stats os.FileInfo, opts Options) (types.UploadResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
 
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = CHUNK_SIZE
+	}
+
 	// Create the TUS client with config
 	config := &tus.Config{
-		ChunkSize:  CHUNK_SIZE, // 50MB chunks
+		ChunkSize:  chunkSize, // 50MB chunks by default
 		Resume:     false,
 		Header:     http.Header{"Authorization": {fmt.Sprintf("Bearer %s", jwt)}},
-		HttpClient: http.DefaultClient,
+		HttpClient: uploadClient(opts),
 	}
 
 	uploadHost := cliConfig.GetUploadsHost()
@@ -211,7 +370,7 @@ func uploadWithTUS(filePath string, groupId string, name string, verbose bool, s
 	}
 	defer f.Close()
 
-	networkParam, err := cliConfig.GetNetworkParam(network)
+	networkParam, err := cliConfig.GetNetworkParam(opts.Network)
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
@@ -221,11 +380,21 @@ func uploadWithTUS(filePath string, groupId string, name string, verbose bool, s
 		"filename": filepath.Base(filePath),
 		"network":  networkParam,
 	}
-	if groupId != "" {
-		metadata["group_id"] = groupId
+	if opts.GroupId != "" {
+		metadata["group_id"] = opts.GroupId
+	}
+	if opts.Name != "nil" {
+		metadata["filename"] = opts.Name
+	}
+	if len(opts.KeyValues) > 0 {
+		keyvaluesBytes, err := json.Marshal(opts.KeyValues)
+		if err != nil {
+			return types.UploadResponse{}, fmt.Errorf("failed to encode keyvalues: %w", err)
+		}
+		metadata["keyvalues"] = string(keyvaluesBytes)
 	}
-	if name != "nil" {
-		metadata["filename"] = name
+	if opts.Car {
+		metadata["car"] = "true"
 	}
 
 	// Create the upload
@@ -238,8 +407,8 @@ func uploadWithTUS(filePath string, groupId string, name string, verbose bool, s
 	}
 
 	var bar *progressbar.ProgressBar
-	if verbose {
-		fmt.Printf("Starting upload of %s (%s)\n", stats.Name(), formatSize(int(stats.Size())))
+	if opts.Verbose {
+		fmt.Printf("Starting upload of %s (%s)\n", stats.Name(), utils.FormatSize(int(stats.Size())))
 		bar = progressbar.NewOptions64(
 			stats.Size(),
 			progressbar.OptionEnableColorCodes(true),
@@ -254,24 +423,18 @@ func uploadWithTUS(filePath string, groupId string, name string, verbose bool, s
 			progressbar.OptionOnCompletion(cmpl),
 		)
 
-		go func() {
-			for {
-				offset := uploader.Offset()
-				if offset >= stats.Size() {
-					return
-				}
-				bar.Set64(offset)
-				time.Sleep(100 * time.Millisecond)
-			}
-		}()
 	}
 
-	err = uploader.Upload()
+	err = uploadChunks(client, uploader.Url(), f, stats.Size(), func(offset int64) {
+		if bar != nil {
+			bar.Set64(offset)
+		}
+	})
 	if err != nil {
 		return types.UploadResponse{}, fmt.Errorf("failed during upload: %w", err)
 	}
 
-	if verbose {
+	if opts.Verbose {
 		fmt.Println("\nUpload completed!")
 	}
 
@@ -312,7 +475,7 @@ func uploadWithTUS(filePath string, groupId string, name string, verbose bool, s
 	return response, nil
 }
 
-func folderUpload(filePath string, groupId string, name string, verbose bool) (types.UploadResponse, error) {
+func folderUpload(filePath string, opts Options) (types.UploadResponse, error) {
 	jwt, err := common.FindToken()
 	if err != nil {
 		return types.UploadResponse{}, err
@@ -323,23 +486,26 @@ func folderUpload(filePath string, groupId string, name string, verbose bool) (t
 		return types.UploadResponse{}, errors.Join(err, errors.New("folder does not exist"))
 	}
 
-	files, err := pathsFinder(filePath, stats)
+	files, err := folderPaths(filePath, stats, opts)
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
+	if len(files) == 0 {
+		return types.UploadResponse{}, errors.New("folder does not contain any files")
+	}
 
 	body := &bytes.Buffer{}
-	contentType, err := createPinataMultipartRequest(filePath, files, body, stats, groupId, name)
+	contentType, err := createPinataMultipartRequest(filePath, files, body, stats, opts)
 	if err != nil {
 		return types.UploadResponse{}, err
 	}
 
 	var requestBody io.Reader
-	if !verbose {
+	if !opts.Verbose {
 		requestBody = body
 	} else {
 		totalSize := int64(body.Len())
-		fmt.Printf("Uploading folder %s (%s)\n", stats.Name(), formatSize(int(totalSize)))
+		fmt.Printf("Uploading folder %s (%s)\n", stats.Name(), utils.FormatSize(int(totalSize)))
 		requestBody = newProgressReader(body, totalSize)
 	}
 
@@ -352,7 +518,7 @@ func folderUpload(filePath string, groupId string, name string, verbose bool) (t
 	req.Header.Set("Authorization", "Bearer "+string(jwt))
 	req.Header.Set("content-type", contentType)
 
-	client := &http.Client{}
+	client := uploadClient(opts)
 	resp, err := client.Do(req)
 	if err != nil {
 		return types.UploadResponse{}, errors.Join(err, errors.New("failed to send the request"))
@@ -398,6 +564,12 @@ func folderUpload(filePath string, groupId string, name string, verbose bool) (t
 			Vectorized    bool              `json:"vectorized"`
 			Network       string            `json:"network,omitempty"`
 			IsDuplicate   bool              `json:"is_duplicate,omitempty"`
+			// NumberOfDuplicates counts the files of a folder upload that the
+			// server reported as already on the network
+			NumberOfDuplicates int `json:"number_of_duplicates,omitempty"`
+			// NumberOfSkipped counts the files of a folder upload that were not
+			// sent because --on-duplicate=skip found them on the network
+			NumberOfSkipped int `json:"number_of_skipped,omitempty"`
 		}{
 			Id:            pinningResponse.ID,
 			Name:          pinningResponse.Name,
@@ -413,10 +585,13 @@ func folderUpload(filePath string, groupId string, name string, verbose bool) (t
 			IsDuplicate:   pinningResponse.IsDuplicate,
 		},
 	}
+	if pinningResponse.IsDuplicate {
+		response.Data.NumberOfDuplicates = pinningResponse.NumberOfFiles
+	}
 
 	// If groupId is specified, set it in the response
-	if groupId != "" {
-		response.Data.GroupId = &groupId
+	if opts.GroupId != "" {
+		response.Data.GroupId = &opts.GroupId
 	}
 
 	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
@@ -429,7 +604,107 @@ func folderUpload(filePath string, groupId string, name string, verbose bool) (t
 	return response, nil
 }
 
-func createMultipartRequest(filePath string, files []string, body io.Writer, stats os.FileInfo, groupId string, name string, network string) (string, error) {
+func privateFolderUpload(filePath string, opts Options) (types.UploadResponse, error) {
+	stats, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return types.UploadResponse{}, errors.Join(err, errors.New("folder does not exist"))
+	}
+
+	files, err := pathsFinder(filePath, stats, opts)
+	if err != nil {
+		return types.UploadResponse{}, err
+	}
+	if len(files) == 0 {
+		return types.UploadResponse{}, errors.New("folder does not contain any files")
+	}
+	if opts.EmptyDirs {
+		warn("empty folders cannot be kept on the private network, each file is uploaded on its own")
+	}
+
+	if opts.OnDuplicate == DUPLICATE_ERROR && opts.Encryption == nil {
+		duplicates, err := findDuplicates(filePath, files, opts)
+		if err != nil {
+			return types.UploadResponse{}, err
+		}
+		if len(duplicates) > 0 {
+			return types.UploadResponse{}, fmt.Errorf("%w: %d files in %s are already uploaded: %s", ErrDuplicate, len(duplicates), filePath, strings.Join(duplicates, ", "))
+		}
+	}
+
+	folderName := stats.Name()
+	if opts.Name != "nil" {
+		folderName = opts.Name
+	}
+
+	// Without a group to upload into, create one named after the folder
+	groupId := opts.GroupId
+	if groupId == "" {
+		group, err := groups.CreateGroup(folderName, opts.Network)
+		if err != nil {
+			return types.UploadResponse{}, errors.Join(err, errors.New("failed to create a group for the folder"))
+		}
+		groupId = group.Data.Id
+	}
+
+	totalSize := 0
+	duplicates := 0
+	skipped := 0
+	for _, f := range files {
+		relPath, err := filepath.Rel(filePath, f)
+		if err != nil {
+			return types.UploadResponse{}, err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		fileStats, err := os.Stat(f)
+		if err != nil {
+			return types.UploadResponse{}, err
+		}
+
+		fileOpts := opts
+		fileOpts.GroupId = groupId
+		fileOpts.Name = relPath
+		fileOpts.KeyValues = make(map[string]string, len(opts.KeyValues)+1)
+		for key, value := range opts.KeyValues {
+			fileOpts.KeyValues[key] = value
+		}
+		fileOpts.KeyValues[types.PathKeyValue] = relPath
+
+		fileResponse, err := uploadPath(f, fileStats, fileOpts)
+		if err != nil {
+			return types.UploadResponse{}, fmt.Errorf("failed to upload %s: %w", relPath, err)
+		}
+		switch {
+		case fileResponse.Data.IsDuplicate && opts.OnDuplicate == DUPLICATE_SKIP:
+			skipped++
+		case fileResponse.Data.IsDuplicate:
+			duplicates++
+		}
+		totalSize += int(fileStats.Size())
+	}
+
+	var response types.UploadResponse
+	response.Data.Name = folderName
+	response.Data.Size = totalSize
+	response.Data.NumberOfFiles = len(files)
+	response.Data.NumberOfDuplicates = duplicates
+	response.Data.NumberOfSkipped = skipped
+	response.Data.GroupId = &groupId
+	response.Data.KeyValues = opts.KeyValues
+	response.Data.Network = opts.Network
+
+	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	if err != nil {
+		return types.UploadResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+	fmt.Printf("Uploaded %d, skipped %d, duplicates %d\n", len(files)-skipped, skipped, duplicates)
+
+	return response, nil
+}
+
+func createMultipartRequest(filePath string, files []string, body io.Writer, stats os.FileInfo, opts Options) (string, error) {
 	contentType := ""
 	writer := multipart.NewWriter(body)
 
@@ -462,46 +737,89 @@ func createMultipartRequest(filePath string, files []string, body io.Writer, sta
 		}
 	}
 
-	networkParam, err := config.GetNetworkParam(network)
+	err := writeUploadFields(writer, opts, stats.Name())
 	if err != nil {
-		return "", err
+		return contentType, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return contentType, err
+	}
+
+	contentType = writer.FormDataContentType()
+
+	return contentType, nil
+}
+
+// writeUploadFields adds the v3 upload options to a multipart request
+func writeUploadFields(writer *multipart.Writer, opts Options, defaultName string) error {
+	networkParam, err := config.GetNetworkParam(opts.Network)
+	if err != nil {
+		return err
 	}
 
 	err = writer.WriteField("network", networkParam)
+	if err != nil {
+		return err
+	}
 
-	if groupId != "" {
-		err := writer.WriteField("group_id", groupId)
+	if opts.GroupId != "" {
+		err := writer.WriteField("group_id", opts.GroupId)
 		if err != nil {
-			return contentType, err
+			return err
 		}
 	}
 
-	nameToUse := stats.Name()
-	if name != "nil" {
-		nameToUse = name
+	nameToUse := defaultName
+	if opts.Name != "nil" {
+		nameToUse = opts.Name
 	}
 	err = writer.WriteField("name", nameToUse)
 	if err != nil {
-		return contentType, err
+		return err
 	}
 
-	err = writer.Close()
-	if err != nil {
-		return contentType, err
+	if len(opts.KeyValues) > 0 {
+		keyvaluesBytes, err := json.Marshal(opts.KeyValues)
+		if err != nil {
+			return err
+		}
+		err = writer.WriteField("keyvalues", string(keyvaluesBytes))
+		if err != nil {
+			return err
+		}
 	}
 
-	contentType = writer.FormDataContentType()
+	if opts.Car {
+		err = writer.WriteField("car", "true")
+		if err != nil {
+			return err
+		}
+	}
 
-	return contentType, nil
+	return nil
 }
 
-func createPinataMultipartRequest(filePath string, files []string, body io.Writer, stats os.FileInfo, groupId string, name string) (string, error) {
+func createPinataMultipartRequest(filePath string, files []string, body io.Writer, stats os.FileInfo, opts Options) (string, error) {
 	contentType := ""
 	writer := multipart.NewWriter(body)
 
 	// Add files to the multipart request
 	fileIsASingleFile := !stats.IsDir()
 	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return contentType, err
+		}
+		if info.IsDir() {
+			err = createDirectoryPart(writer, filePath, f, stats.Name())
+			if err != nil {
+				return contentType, err
+			}
+			continue
+		}
+
 		file, err := os.Open(f)
 		if err != nil {
 			return contentType, err
@@ -546,8 +864,8 @@ func createPinataMultipartRequest(filePath string, files []string, body io.Write
 	}
 
 	// Add groupId to options if provided
-	if groupId != "" {
-		pinataOptions.GroupId = groupId
+	if opts.GroupId != "" {
+		pinataOptions.GroupId = opts.GroupId
 	}
 
 	optionsBytes, err := json.Marshal(pinataOptions)
@@ -562,13 +880,13 @@ func createPinataMultipartRequest(filePath string, files []string, body io.Write
 
 	// Create and add PinataMetadata
 	nameToUse := stats.Name()
-	if name != "nil" {
-		nameToUse = name
+	if opts.Name != "nil" {
+		nameToUse = opts.Name
 	}
 
 	pinataMetadata := types.PinataMetadata{
 		Name:      nameToUse,
-		KeyValues: make(map[string]string), // Empty keyvalues for now
+		KeyValues: opts.KeyValues,
 	}
 
 	metadataBytes, err := json.Marshal(pinataMetadata)
@@ -590,7 +908,22 @@ func createPinataMultipartRequest(filePath string, files []string, body io.Write
 	return contentType, nil
 }
 
-func pathsFinder(filePath string, stats os.FileInfo) ([]string, error) {
+// createDirectoryPart adds an empty folder to a folder upload, using the
+// application/x-directory content type IPFS uses for directory entries
+func createDirectoryPart(writer *multipart.Writer, filePath string, dir string, folderName string) error {
+	relPath, err := filepath.Rel(filePath, dir)
+	if err != nil {
+		return err
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, path.Join(folderName, filepath.ToSlash(relPath))))
+	h.Set("Content-Type", "application/x-directory")
+	_, err = writer.CreatePart(h)
+	return err
+}
+
+func pathsFinder(filePath string, stats os.FileInfo, opts Options) ([]string, error) {
 	var err error
 	files := make([]string, 0)
 	fileIsASingleFile := !stats.IsDir()
@@ -598,20 +931,26 @@ func pathsFinder(filePath string, stats os.FileInfo) ([]string, error) {
 		files = append(files, filePath)
 		return files, err
 	}
-	err = filepath.Walk(filePath,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() {
-				files = append(files, path)
-			}
-			return nil
-		})
 
+	files, _, err = walkFolder(filePath, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return files, err
 }
+
+// folderPaths is pathsFinder plus, when opts.EmptyDirs is set, the empty
+// folders to keep in the uploaded folder
+func folderPaths(filePath string, stats os.FileInfo, opts Options) ([]string, error) {
+	if !stats.IsDir() || !opts.EmptyDirs {
+		return pathsFinder(filePath, stats, opts)
+	}
+
+	files, emptyDirs, err := walkFolder(filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, emptyDirs...), nil
+}
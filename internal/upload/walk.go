@@ -0,0 +1,177 @@
+package uploads
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	SYMLINKS_FOLLOW = "follow" // Upload what the link points to
+	SYMLINKS_SKIP   = "skip"   // Leave links out of the upload
+	SYMLINKS_ERROR  = "error"  // Fail the upload when a link is found
+)
+
+// folderWalker collects the files in a folder upload, applying ignore
+// patterns and the symlink policy
+type folderWalker struct {
+	root    string
+	opts    Options
+	ignore  *ignoreMatcher
+	include *ignoreMatcher
+	// ancestors holds the resolved paths of the folders being walked, so a
+	// link back to one of them is caught instead of recursing forever
+	ancestors []string
+	files     []string
+	emptyDirs []string
+}
+
+// walkFolder returns the files under root that should be uploaded, and with
+// EmptyDirs the folders that are empty on disk. Folders emptied by filtering
+// are dropped. Special files such as FIFOs, sockets and devices are skipped
+// with a warning
+func walkFolder(root string, opts Options) ([]string, []string, error) {
+	switch opts.Symlinks {
+	case "":
+		opts.Symlinks = SYMLINKS_FOLLOW
+	case SYMLINKS_FOLLOW, SYMLINKS_SKIP, SYMLINKS_ERROR:
+	default:
+		return nil, nil, fmt.Errorf("invalid symlinks option %q. Must be follow, skip or error", opts.Symlinks)
+	}
+
+	ignoreLines, err := loadIgnoreFile(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	realRoot, err := resolvePath(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := &folderWalker{
+		root:      root,
+		opts:      opts,
+		ignore:    newIgnoreMatcher(append(ignoreLines, opts.Exclude...)),
+		include:   newIgnoreMatcher(opts.Include),
+		ancestors: []string{realRoot},
+		files:     make([]string, 0),
+		emptyDirs: make([]string, 0),
+	}
+
+	_, _, err = w.walkDir(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return w.files, w.emptyDirs, nil
+}
+
+// walkDir reports whether anything under dir was kept, and whether dir was
+// empty on disk
+func (w *folderWalker) walkDir(dir string) (bool, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, false, err
+	}
+
+	kept := false
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		relPath, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return false, false, err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		// Ignored entries are skipped before the symlink policy applies, so an
+		// ignored link never fails the walk. Like git, a link does not match
+		// dir-only patterns even when it points at a folder
+		skip := relPath == IGNORE_FILE ||
+			(writesReceipts(w.opts) && isReceipt(entry.Name())) ||
+			(w.opts.NoHidden && isHidden(entry.Name())) ||
+			w.ignore.match(relPath, entry.Type().IsDir())
+		if skip {
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return false, false, err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			switch w.opts.Symlinks {
+			case SYMLINKS_SKIP:
+				continue
+			case SYMLINKS_ERROR:
+				return false, false, fmt.Errorf("%s is a symbolic link, use --symlinks=follow or --symlinks=skip", relPath)
+			}
+			info, err = os.Stat(path)
+			if err != nil {
+				warn("skipping broken symbolic link %s", relPath)
+				continue
+			}
+		}
+
+		switch {
+		case info.IsDir():
+			realPath, err := resolvePath(path)
+			if err != nil {
+				return false, false, err
+			}
+			if w.isAncestor(realPath) {
+				warn("skipping %s, symbolic link cycle back to %s", relPath, realPath)
+				continue
+			}
+
+			w.ancestors = append(w.ancestors, realPath)
+			childKept, childEmpty, err := w.walkDir(path)
+			w.ancestors = w.ancestors[:len(w.ancestors)-1]
+			if err != nil {
+				return false, false, err
+			}
+
+			if childKept {
+				kept = true
+			} else if w.opts.EmptyDirs && childEmpty {
+				// Only folders that were empty on disk are kept, not ones
+				// emptied by filtering
+				w.emptyDirs = append(w.emptyDirs, path)
+				kept = true
+			}
+		case !info.Mode().IsRegular():
+			warn("skipping %s, not a regular file", relPath)
+		default:
+			if len(w.include.patterns) > 0 && !w.include.includes(relPath) {
+				continue
+			}
+			w.files = append(w.files, path)
+			kept = true
+		}
+	}
+
+	return kept, len(entries) == 0, nil
+}
+
+func (w *folderWalker) isAncestor(realPath string) bool {
+	for _, ancestor := range w.ancestors {
+		if ancestor == realPath {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns the absolute path with every symbolic link resolved
+func resolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(absPath)
+}
+
+func warn(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+}
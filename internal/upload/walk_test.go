@@ -0,0 +1,57 @@
+package uploads
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestWalkFolderKeepEmptyDirs(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"src/main.go", "docs/readme.md", "docs/guide/intro.md"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.MkdirAll(filepath.Join(root, "assets", "empty"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, emptyDirs, err := walkFolder(root, Options{Include: []string{"*.go"}, EmptyDirs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relPaths := func(paths []string) []string {
+		out := make([]string, 0, len(paths))
+		for _, p := range paths {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, filepath.ToSlash(rel))
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	gotFiles := relPaths(files)
+	if len(gotFiles) != 1 || gotFiles[0] != "src/main.go" {
+		t.Errorf("files = %v, want [src/main.go]", gotFiles)
+	}
+
+	// docs only lost its files to --include, so only the folder that was
+	// empty on disk is kept
+	gotDirs := relPaths(emptyDirs)
+	if len(gotDirs) != 1 || gotDirs[0] != "assets/empty" {
+		t.Errorf("empty dirs = %v, want [assets/empty]", gotDirs)
+	}
+}
@@ -0,0 +1,125 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"pinata/internal/types"
+	"time"
+)
+
+type watchedFile struct {
+	size     int64
+	modTime  time.Time
+	uploaded bool
+}
+
+// Watch polls a folder and uploads files that are new or modified once they
+// have stopped changing for a full interval, so partially written files are
+// not uploaded. Files present when watching starts are not uploaded. Failed
+// uploads are logged and retried on the next poll
+func Watch(dir string, opts Options, interval time.Duration) error {
+	stats, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !stats.IsDir() {
+		return errors.New("watch requires a folder")
+	}
+	if interval <= 0 {
+		return errors.New("watch interval must be greater than zero")
+	}
+
+	state, err := scanFolder(dir, stats, opts)
+	if err != nil {
+		return err
+	}
+	for _, file := range state {
+		file.uploaded = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Watching %s for changes, press Ctrl+C to stop", dir)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopped watching")
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := scanFolder(dir, stats, opts)
+		if err != nil {
+			log.Printf("failed to scan %s: %v", dir, err)
+			continue
+		}
+
+		for path, file := range current {
+			previous, ok := state[path]
+			if !ok || previous.size != file.size || !previous.modTime.Equal(file.modTime) {
+				// New or still changing, check again on the next poll
+				state[path] = file
+				continue
+			}
+			if previous.uploaded {
+				continue
+			}
+
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				log.Printf("failed to upload %s: %v", path, err)
+				continue
+			}
+			relPath = filepath.ToSlash(relPath)
+
+			fileOpts := opts
+			fileOpts.Name = relPath
+			fileOpts.KeyValues = make(map[string]string, len(opts.KeyValues)+1)
+			for key, value := range opts.KeyValues {
+				fileOpts.KeyValues[key] = value
+			}
+			fileOpts.KeyValues[types.PathKeyValue] = relPath
+
+			response, err := Upload(path, fileOpts)
+			if err != nil {
+				log.Printf("failed to upload %s: %v", relPath, err)
+				continue
+			}
+			previous.uploaded = true
+			log.Printf("uploaded %s (%s)", relPath, response.Data.Cid)
+		}
+
+		for path := range state {
+			if _, ok := current[path]; !ok {
+				delete(state, path)
+			}
+		}
+	}
+}
+
+func scanFolder(dir string, stats os.FileInfo, opts Options) (map[string]*watchedFile, error) {
+	paths, err := pathsFinder(dir, stats, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]*watchedFile, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			// Removed between the walk and the stat
+			continue
+		}
+		state[path] = &watchedFile{size: info.Size(), modTime: info.ModTime()}
+	}
+	return state, nil
+}
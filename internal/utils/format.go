@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatSize formats a byte count for display
+func FormatSize(bytes int) string {
+	const (
+		KB = 1000
+		MB = KB * KB
+		GB = MB * KB
+	)
+
+	var formattedSize string
+
+	switch {
+	case bytes < KB:
+		formattedSize = fmt.Sprintf("%d bytes", bytes)
+	case bytes < MB:
+		formattedSize = fmt.Sprintf("%.2f KB", float64(bytes)/KB)
+	case bytes < GB:
+		formattedSize = fmt.Sprintf("%.2f MB", float64(bytes)/MB)
+	default:
+		formattedSize = fmt.Sprintf("%.2f GB", float64(bytes)/GB)
+	}
+
+	return formattedSize
+}
+
+// ParseDate parses a filter date. An upper bound is returned as the first
+// time past the range, so a plain date includes the whole day and an RFC3339
+// time includes that instant
+func ParseDate(value string, upperBound bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if upperBound {
+			t = t.Add(time.Nanosecond)
+		}
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC3339", value)
+	}
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
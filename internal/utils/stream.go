@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"encoding/json"
+	"iter"
+	"os"
+	"strconv"
+)
+
+// MAX_PAGE_SIZE is the largest limit asked for when following pages, the
+// documented maximum of the list endpoints (pageLimit on pinList). A server
+// that caps pages lower still sends a next_page_token, so a lower cap only
+// means more requests, never missed results
+const MAX_PAGE_SIZE = 1000
+
+// PageSize picks the page size used to follow pages. An amount given by the
+// user is kept, otherwise pages are as large as the API allows, or just max
+// when fewer results are wanted
+func PageSize(amount string, amountSet bool, max int) string {
+	if amountSet {
+		return amount
+	}
+	if max > 0 && max < MAX_PAGE_SIZE {
+		return strconv.Itoa(max)
+	}
+	return strconv.Itoa(MAX_PAGE_SIZE)
+}
+
+// StreamJSON prints each item as one line of JSON as soon as it arrives, so
+// large accounts can be piped into other tools without waiting for every
+// page. It stops after max items, or at the end when max is 0
+func StreamJSON[T any](items iter.Seq2[T, error], max int) (int, error) {
+	encoder := json.NewEncoder(os.Stdout)
+	count := 0
+	for item, err := range items {
+		if err != nil {
+			return count, err
+		}
+		err = encoder.Encode(item)
+		if err != nil {
+			return count, err
+		}
+		count++
+		if max > 0 && count >= max {
+			// Stop before the next page is requested
+			break
+		}
+	}
+	return count, nil
+}
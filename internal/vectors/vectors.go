@@ -0,0 +1,139 @@
+package vectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"pinata/internal/common"
+	"pinata/internal/config"
+	"pinata/internal/types"
+	"sort"
+)
+
+// VectorizeFile creates embeddings for a private file so it can be found
+// with QueryVectors. The file must be in a group
+func VectorizeFile(fileId string) (types.VectorizeResponse, error) {
+	jwt, err := common.FindToken()
+	if err != nil {
+		return types.VectorizeResponse{}, err
+	}
+
+	url := fmt.Sprintf("https://%s/v3/vectorize/files/%s", config.GetUploadsHost(), fileId)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return types.VectorizeResponse{}, errors.Join(err, errors.New("failed to create the request"))
+	}
+	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.VectorizeResponse{}, errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return types.VectorizeResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	var response types.VectorizeResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return types.VectorizeResponse{}, err
+	}
+
+	return response, nil
+}
+
+// QueryVectors runs a semantic search over the vectorized files in a group
+// and prints the matches, best first
+func QueryVectors(groupId string, text string) (types.VectorQueryResponse, error) {
+	jwt, err := common.FindToken()
+	if err != nil {
+		return types.VectorQueryResponse{}, err
+	}
+
+	payload := types.VectorQueryBody{
+		Text: text,
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return types.VectorQueryResponse{}, errors.Join(err, errors.New("failed to marshal payload"))
+	}
+
+	url := fmt.Sprintf("https://%s/v3/vectorize/groups/%s/query", config.GetUploadsHost(), groupId)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return types.VectorQueryResponse{}, errors.Join(err, errors.New("failed to create the request"))
+	}
+	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return types.VectorQueryResponse{}, errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return types.VectorQueryResponse{}, fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	var response types.VectorQueryResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return types.VectorQueryResponse{}, err
+	}
+
+	if response.Data.Matches == nil {
+		response.Data.Matches = []types.VectorQueryMatch{}
+	}
+	sort.SliceStable(response.Data.Matches, func(i, j int) bool {
+		return response.Data.Matches[i].Score > response.Data.Matches[j].Score
+	})
+
+	formattedJSON, err := json.MarshalIndent(response.Data, "", "    ")
+	if err != nil {
+		return types.VectorQueryResponse{}, errors.New("failed to format JSON")
+	}
+
+	fmt.Println(string(formattedJSON))
+
+	return response, nil
+}
+
+// DeleteVectors removes the embeddings for a file, leaving the file itself
+func DeleteVectors(fileId string) error {
+	jwt, err := common.FindToken()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://%s/v3/vectorize/files/%s", config.GetUploadsHost(), fileId)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to create the request"))
+	}
+	req.Header.Set("Authorization", "Bearer "+string(jwt))
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Join(err, errors.New("failed to send the request"))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("server Returned an error %d", resp.StatusCode)
+	}
+
+	fmt.Println("Vectors Deleted")
+
+	return nil
+}
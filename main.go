@@ -5,16 +5,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"pinata/internal/auth"
+	"pinata/internal/cids"
 	"pinata/internal/config"
+	"pinata/internal/encryption"
 	"pinata/internal/files"
 	"pinata/internal/gateways"
 	"pinata/internal/groups"
 	"pinata/internal/keys"
+	"pinata/internal/ledger"
+	"pinata/internal/pins"
 	uploads "pinata/internal/upload"
+	"pinata/internal/utils"
+	"pinata/internal/vectors"
 
 	"github.com/urfave/cli/v2"
 )
@@ -77,17 +85,622 @@ func main() {
 						Aliases: []string{"net"},
 						Usage:   "Specify the network (public or private). Uses default if not specified",
 					},
+					&cli.StringSliceFlag{
+						Name:    "keyvalues",
+						Aliases: []string{"kv"},
+						Usage:   "Add metadata keyvalues to the upload (format: key=value)",
+					},
+					&cli.StringFlag{
+						Name:  "kv-file",
+						Usage: "Path to a JSON file of metadata keyvalues to add to the upload",
+					},
+					&cli.StringSliceFlag{
+						Name:    "exclude",
+						Aliases: []string{"x"},
+						Usage:   "Skip files in a folder matching a .gitignore style pattern",
+					},
+					&cli.StringSliceFlag{
+						Name:    "include",
+						Aliases: []string{"i"},
+						Usage:   "Only upload files in a folder matching a .gitignore style pattern",
+					},
+					&cli.BoolFlag{
+						Name:  "no-hidden",
+						Usage: "Skip hidden files and folders when uploading a folder",
+					},
+					&cli.StringFlag{
+						Name:  "symlinks",
+						Value: "follow",
+						Usage: "How to handle symbolic links in a folder: follow, skip or error",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-empty-dirs",
+						Usage: "Keep empty folders in the uploaded folder",
+					},
+					&cli.StringFlag{
+						Name:  "on-duplicate",
+						Value: "allow",
+						Usage: "What to do when the content is already on the network: allow, skip, error or rename",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-existing",
+						Usage: "Shorthand for --on-duplicate=skip",
+					},
+					&cli.StringFlag{
+						Name:  "car",
+						Usage: "Upload a CARv1 or CARv2 file as its DAG, keeping the root CID it was built with",
+					},
+					&cli.BoolFlag{
+						Name:  "verify",
+						Usage: "Compute the CID locally after uploading and fail if it does not match the uploaded CID",
+					},
+					&cli.StringFlag{
+						Name:  "signed-url",
+						Usage: "Upload a single file to a presigned upload URL instead of using your JWT",
+					},
+					&cli.BoolFlag{
+						Name:  "vectorize",
+						Usage: "Vectorize uploaded files so they can be searched with 'pinata vectors query'. Private files in a group only",
+					},
+					&cli.BoolFlag{
+						Name:  "encrypt",
+						Usage: "Encrypt files with age before uploading. Uses your CLI identity unless --recipient or --passphrase is given",
+					},
+					&cli.StringSliceFlag{
+						Name:    "recipient",
+						Aliases: []string{"r"},
+						Usage:   "Also encrypt to an age X25519 recipient (age1...). Your CLI identity is always included when it exists",
+					},
+					&cli.BoolFlag{
+						Name:  "passphrase",
+						Usage: "Encrypt with a passphrase, read from PINATA_ENCRYPTION_PASSPHRASE or asked for",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the network, method, metadata, files and CID for the upload without sending anything",
+					},
+					&cli.BoolFlag{
+						Name:  "receipt",
+						Usage: "Write a .pinata.json receipt next to each uploaded path with the upload response, gateway URL and SHA-256",
+					},
+					&cli.StringFlag{
+						Name:  "receipt-dir",
+						Usage: "Collect the receipts of every uploaded path in one receipts.pinata.json manifest in this folder",
+					},
+					&cli.StringFlag{
+						Name:  "limit-rate",
+						Usage: "Limit upload bandwidth, e.g. 5MB/s. Uses the config default if not specified",
+					},
+					&cli.StringFlag{
+						Name:  "chunk-size",
+						Usage: "Size of each chunk for resumable uploads, e.g. 50MB. Uses the config default if not specified",
+					},
+					&cli.StringFlag{
+						Name:  "resumable-threshold",
+						Usage: "Files larger than this are uploaded in resumable chunks, e.g. 100MB. Uses the config default if not specified",
+					},
+					&cli.BoolFlag{
+						Name:    "watch",
+						Aliases: []string{"w"},
+						Usage:   "Watch a folder and upload new or modified files once they stop changing",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Value: 2 * time.Second,
+						Usage: "How often to check the folder for changes in watch mode",
+					},
 				},
 				Action: func(ctx *cli.Context) error {
 					filePath := ctx.Args().First()
-					groupId := ctx.String("group")
-					name := ctx.String("name")
-					verbose := ctx.Bool("verbose")
-					network := ctx.String("network")
+					carPath := ctx.String("car")
+					if carPath != "" {
+						if filePath != "" {
+							return errors.New("pass either a file path or --car, not both")
+						}
+						filePath = carPath
+					}
 					if filePath == "" {
 						return errors.New("no file path provided")
 					}
-					_, err := uploads.Upload(filePath, groupId, name, verbose, network)
+					keyvalues, err := uploads.ParseKeyValues(ctx.StringSlice("keyvalues"), ctx.String("kv-file"))
+					if err != nil {
+						return err
+					}
+					limitRate, err := config.GetLimitRateParam(ctx.String("limit-rate"))
+					if err != nil {
+						return err
+					}
+					chunkSize, err := config.GetSizeParam(config.ChunkSizeSetting, ctx.String("chunk-size"))
+					if err != nil {
+						return err
+					}
+					resumableThreshold, err := config.GetSizeParam(config.ResumableThresholdSetting, ctx.String("resumable-threshold"))
+					if err != nil {
+						return err
+					}
+					var enc *encryption.Encryption
+					if ctx.Bool("encrypt") {
+						enc, err = encryption.NewEncryption(ctx.StringSlice("recipient"), ctx.Bool("passphrase"))
+						if err != nil {
+							return err
+						}
+					} else if len(ctx.StringSlice("recipient")) > 0 || ctx.Bool("passphrase") {
+						return errors.New("--recipient and --passphrase require --encrypt")
+					}
+					onDuplicate := ctx.String("on-duplicate")
+					if ctx.Bool("skip-existing") {
+						if ctx.IsSet("on-duplicate") {
+							return errors.New("--skip-existing is shorthand for --on-duplicate=skip, use only one of them")
+						}
+						onDuplicate = uploads.DUPLICATE_SKIP
+					}
+					opts := uploads.Options{
+						GroupId:            ctx.String("group"),
+						Name:               ctx.String("name"),
+						Verbose:            ctx.Bool("verbose"),
+						Network:            ctx.String("network"),
+						KeyValues:          keyvalues,
+						Exclude:            ctx.StringSlice("exclude"),
+						Include:            ctx.StringSlice("include"),
+						NoHidden:           ctx.Bool("no-hidden"),
+						Symlinks:           ctx.String("symlinks"),
+						EmptyDirs:          ctx.Bool("keep-empty-dirs"),
+						Receipt:            ctx.Bool("receipt"),
+						ReceiptDir:         ctx.String("receipt-dir"),
+						OnDuplicate:        onDuplicate,
+						Car:                carPath != "",
+						Verify:             ctx.Bool("verify"),
+						LimitRate:          limitRate,
+						SignedURL:          ctx.String("signed-url"),
+						Vectorize:          ctx.Bool("vectorize"),
+						Encryption:         enc,
+						ChunkSize:          chunkSize,
+						ResumableThreshold: resumableThreshold,
+					}
+					if ctx.Bool("dry-run") {
+						_, err := uploads.DryRun(filePath, opts)
+						return err
+					}
+					if ctx.Bool("watch") {
+						return uploads.Watch(filePath, opts, ctx.Duration("interval"))
+					}
+					_, err = uploads.Upload(filePath, opts)
+					return err
+				},
+			},
+			{
+				Name:      "sync",
+				Usage:     "Mirror a local folder into a group, uploading new or changed files",
+				ArgsUsage: "[path to folder]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "group",
+						Aliases:  []string{"g"},
+						Usage:    "ID of the group to keep in sync with the folder",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "delete",
+						Usage: "Delete files from the group that are no longer in the folder or were replaced",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Print what would be uploaded and deleted without changing anything",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "Show upload progress",
+					},
+					&cli.StringFlag{
+						Name:    "network",
+						Aliases: []string{"net"},
+						Usage:   "Specify the network (public or private). Uses default if not specified",
+					},
+					&cli.StringSliceFlag{
+						Name:    "keyvalues",
+						Aliases: []string{"kv"},
+						Usage:   "Add metadata keyvalues to uploaded files (format: key=value)",
+					},
+					&cli.StringFlag{
+						Name:  "kv-file",
+						Usage: "Path to a JSON file of metadata keyvalues to add to uploaded files",
+					},
+					&cli.StringSliceFlag{
+						Name:    "exclude",
+						Aliases: []string{"x"},
+						Usage:   "Skip files in the folder matching a .gitignore style pattern",
+					},
+					&cli.StringSliceFlag{
+						Name:    "include",
+						Aliases: []string{"i"},
+						Usage:   "Only sync files in the folder matching a .gitignore style pattern",
+					},
+					&cli.BoolFlag{
+						Name:  "no-hidden",
+						Usage: "Skip hidden files and folders",
+					},
+					&cli.StringFlag{
+						Name:  "symlinks",
+						Value: "follow",
+						Usage: "How to handle symbolic links in a folder: follow, skip or error",
+					},
+					&cli.StringFlag{
+						Name:  "limit-rate",
+						Usage: "Limit upload bandwidth, e.g. 5MB/s. Uses the config default if not specified",
+					},
+					&cli.StringFlag{
+						Name:  "chunk-size",
+						Usage: "Size of each chunk for resumable uploads, e.g. 50MB. Uses the config default if not specified",
+					},
+					&cli.StringFlag{
+						Name:  "resumable-threshold",
+						Usage: "Files larger than this are uploaded in resumable chunks, e.g. 100MB. Uses the config default if not specified",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					dir := ctx.Args().First()
+					if dir == "" {
+						return errors.New("no folder path provided")
+					}
+					keyvalues, err := uploads.ParseKeyValues(ctx.StringSlice("keyvalues"), ctx.String("kv-file"))
+					if err != nil {
+						return err
+					}
+					limitRate, err := config.GetLimitRateParam(ctx.String("limit-rate"))
+					if err != nil {
+						return err
+					}
+					chunkSize, err := config.GetSizeParam(config.ChunkSizeSetting, ctx.String("chunk-size"))
+					if err != nil {
+						return err
+					}
+					resumableThreshold, err := config.GetSizeParam(config.ResumableThresholdSetting, ctx.String("resumable-threshold"))
+					if err != nil {
+						return err
+					}
+					opts := uploads.Options{
+						GroupId:            ctx.String("group"),
+						Verbose:            ctx.Bool("verbose"),
+						Network:            ctx.String("network"),
+						KeyValues:          keyvalues,
+						Exclude:            ctx.StringSlice("exclude"),
+						Include:            ctx.StringSlice("include"),
+						NoHidden:           ctx.Bool("no-hidden"),
+						Symlinks:           ctx.String("symlinks"),
+						LimitRate:          limitRate,
+						ChunkSize:          chunkSize,
+						ResumableThreshold: resumableThreshold,
+					}
+					return uploads.Sync(dir, opts, ctx.Bool("delete"), ctx.Bool("dry-run"))
+				},
+			},
+			{
+				Name:    "history",
+				Aliases: []string{"hist"},
+				Usage:   "Search the local history of uploads made with the CLI",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "path",
+						Aliases: []string{"p"},
+						Usage:   "Filter by part of the local path that was uploaded",
+					},
+					&cli.StringFlag{
+						Name:    "cid",
+						Aliases: []string{"c"},
+						Usage:   "Filter by CID",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "Only show uploads on or after this date (YYYY-MM-DD or RFC3339)",
+					},
+					&cli.StringFlag{
+						Name:  "until",
+						Usage: "Only show uploads on or before this date (YYYY-MM-DD or RFC3339)",
+					},
+					&cli.IntFlag{
+						Name:    "amount",
+						Aliases: []string{"a"},
+						Usage:   "The number of uploads you would like to return, newest first",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					path := ctx.String("path")
+					cid := ctx.String("cid")
+					since := ctx.String("since")
+					until := ctx.String("until")
+					amount := ctx.Int("amount")
+					_, err := ledger.ListHistory(path, cid, since, until, amount)
+					return err
+				},
+			},
+			{
+				Name:      "cid",
+				Usage:     "Compute the CID of a file or folder locally without uploading it",
+				ArgsUsage: "[path to file or folder]",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "cid-version",
+						Value: 1,
+						Usage: "CID version to compute (0 or 1)",
+					},
+					&cli.BoolFlag{
+						Name:  "raw-leaves",
+						Usage: "Use raw blocks for file data instead of wrapping them in UnixFS nodes. Defaults to true for CIDv1 and false for CIDv0",
+					},
+					&cli.StringSliceFlag{
+						Name:    "exclude",
+						Aliases: []string{"x"},
+						Usage:   "Skip files in a folder matching a .gitignore style pattern",
+					},
+					&cli.StringSliceFlag{
+						Name:    "include",
+						Aliases: []string{"i"},
+						Usage:   "Only include files in a folder matching a .gitignore style pattern",
+					},
+					&cli.BoolFlag{
+						Name:  "no-hidden",
+						Usage: "Skip hidden files and folders",
+					},
+					&cli.StringFlag{
+						Name:  "symlinks",
+						Value: "follow",
+						Usage: "How to handle symbolic links in a folder: follow, skip or error",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-empty-dirs",
+						Usage: "Keep empty folders in the uploaded folder",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					filePath := ctx.Args().First()
+					if filePath == "" {
+						return errors.New("no file path provided")
+					}
+					opts := uploads.Options{
+						Exclude:   ctx.StringSlice("exclude"),
+						Include:   ctx.StringSlice("include"),
+						NoHidden:  ctx.Bool("no-hidden"),
+						Symlinks:  ctx.String("symlinks"),
+						EmptyDirs: ctx.Bool("keep-empty-dirs"),
+					}
+					cidOpts := cids.DefaultOptions()
+					cidOpts.Version = ctx.Int("cid-version")
+					cidOpts.RawLeaves = cidOpts.Version == 1
+					if ctx.IsSet("raw-leaves") {
+						cidOpts.RawLeaves = ctx.Bool("raw-leaves")
+					}
+					cid, err := uploads.ComputeCID(filePath, opts, cidOpts)
+					if err != nil {
+						return err
+					}
+					fmt.Println(cid)
+					return nil
+				},
+			},
+			{
+				Name:  "car",
+				Usage: "Work with CAR files",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "pack",
+						Aliases:   []string{"p"},
+						Usage:     "Build a CAR file locally from a file or folder",
+						ArgsUsage: "[path to file or folder]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Path to write the CAR file to, use - for stdout. Defaults to the name of the file or folder with a .car extension",
+							},
+							&cli.IntFlag{
+								Name:  "cid-version",
+								Value: 1,
+								Usage: "CID version to build with (0 or 1)",
+							},
+							&cli.BoolFlag{
+								Name:  "raw-leaves",
+								Usage: "Use raw blocks for file data instead of wrapping them in UnixFS nodes. Defaults to true for CIDv1 and false for CIDv0",
+							},
+							&cli.StringSliceFlag{
+								Name:    "exclude",
+								Aliases: []string{"x"},
+								Usage:   "Skip files in a folder matching a .gitignore style pattern",
+							},
+							&cli.StringSliceFlag{
+								Name:    "include",
+								Aliases: []string{"i"},
+								Usage:   "Only include files in a folder matching a .gitignore style pattern",
+							},
+							&cli.BoolFlag{
+								Name:  "no-hidden",
+								Usage: "Skip hidden files and folders",
+							},
+							&cli.StringFlag{
+								Name:  "symlinks",
+								Value: "follow",
+								Usage: "How to handle symbolic links in a folder: follow, skip or error",
+							},
+							&cli.BoolFlag{
+								Name:  "keep-empty-dirs",
+								Usage: "Keep empty folders in the uploaded folder",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							filePath := ctx.Args().First()
+							output := ctx.String("output")
+							if filePath == "" {
+								return errors.New("no file path provided")
+							}
+							if ctx.Args().Len() > 1 {
+								return errors.New("options must come before the path, e.g. pinata car pack -o out.car ./folder")
+							}
+							if output == "" {
+								output = filepath.Base(filepath.Clean(filePath)) + ".car"
+							}
+							opts := uploads.Options{
+								Exclude:   ctx.StringSlice("exclude"),
+								Include:   ctx.StringSlice("include"),
+								NoHidden:  ctx.Bool("no-hidden"),
+								Symlinks:  ctx.String("symlinks"),
+								EmptyDirs: ctx.Bool("keep-empty-dirs"),
+							}
+							cidOpts := cids.DefaultOptions()
+							cidOpts.Version = ctx.Int("cid-version")
+							cidOpts.RawLeaves = cidOpts.Version == 1
+							if ctx.IsSet("raw-leaves") {
+								cidOpts.RawLeaves = ctx.Bool("raw-leaves")
+							}
+							root, err := uploads.PackCAR(filePath, output, opts, cidOpts)
+							if err != nil {
+								return err
+							}
+							if output != "-" {
+								fmt.Println(root)
+							}
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:    "upload-url",
+				Aliases: []string{"uu"},
+				Usage:   "Create presigned URLs that allow uploads without your JWT",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "create",
+						Aliases: []string{"c"},
+						Usage:   "Create a presigned URL for uploading a single file",
+						Flags: []cli.Flag{
+							&cli.DurationFlag{
+								Name:    "expires",
+								Aliases: []string{"e"},
+								Value:   10 * time.Minute,
+								Usage:   "How long the URL is valid for, e.g. 10m or 24h",
+							},
+							&cli.StringFlag{
+								Name:    "group",
+								Aliases: []string{"g"},
+								Usage:   "Upload the file to a specific group by passing in the groupId",
+							},
+							&cli.StringFlag{
+								Name:    "name",
+								Aliases: []string{"n"},
+								Value:   "nil",
+								Usage:   "Name to give the uploaded file",
+							},
+							&cli.StringFlag{
+								Name:  "max-size",
+								Usage: "Largest file that can be uploaded with the URL, e.g. 50MB",
+							},
+							&cli.StringSliceFlag{
+								Name:  "mime",
+								Usage: "Only allow uploads of a MIME type, wildcards like image/* are supported",
+							},
+							&cli.StringFlag{
+								Name:    "network",
+								Aliases: []string{"net"},
+								Usage:   "Specify the network (public or private). Uses default if not specified",
+							},
+							&cli.StringSliceFlag{
+								Name:    "keyvalues",
+								Aliases: []string{"kv"},
+								Usage:   "Add metadata keyvalues to the upload (format: key=value)",
+							},
+							&cli.StringFlag{
+								Name:  "kv-file",
+								Usage: "Path to a JSON file of metadata keyvalues to add to the upload",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							var maxSize int64
+							if ctx.String("max-size") != "" {
+								var err error
+								maxSize, err = config.ParseSize(ctx.String("max-size"))
+								if err != nil {
+									return err
+								}
+							}
+							keyvalues, err := uploads.ParseKeyValues(ctx.StringSlice("keyvalues"), ctx.String("kv-file"))
+							if err != nil {
+								return err
+							}
+							opts := uploads.Options{
+								GroupId:   ctx.String("group"),
+								Name:      ctx.String("name"),
+								Network:   ctx.String("network"),
+								KeyValues: keyvalues,
+							}
+							expires := int(ctx.Duration("expires").Seconds())
+							_, err = uploads.CreateUploadURL(expires, maxSize, ctx.StringSlice("mime"), opts)
+							return err
+						},
+					},
+				},
+			},
+			{
+				Name:      "upload-json",
+				Aliases:   []string{"uj"},
+				Usage:     "Upload a JSON document from a string, a file or stdin",
+				ArgsUsage: "[JSON string or - for stdin]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "file",
+						Aliases: []string{"f"},
+						Usage:   "Path to a JSON file to upload",
+					},
+					&cli.StringFlag{
+						Name:    "group",
+						Aliases: []string{"g"},
+						Value:   "",
+						Usage:   "Upload the JSON to a specific group by passing in the groupId",
+					},
+					&cli.StringFlag{
+						Name:    "name",
+						Aliases: []string{"n"},
+						Value:   "nil",
+						Usage:   "Add a name for the JSON file. By default it will be data.json",
+					},
+					&cli.StringFlag{
+						Name:    "network",
+						Aliases: []string{"net"},
+						Usage:   "Specify the network (public or private). Uses default if not specified",
+					},
+					&cli.StringSliceFlag{
+						Name:    "keyvalues",
+						Aliases: []string{"kv"},
+						Usage:   "Add metadata keyvalues to the upload (format: key=value)",
+					},
+					&cli.StringFlag{
+						Name:  "kv-file",
+						Usage: "Path to a JSON file of metadata keyvalues to add to the upload",
+					},
+					&cli.StringFlag{
+						Name:  "limit-rate",
+						Usage: "Limit upload bandwidth, e.g. 5MB/s. Uses the config default if not specified",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					data, err := uploads.ReadJSONInput(ctx.Args().First(), ctx.String("file"))
+					if err != nil {
+						return err
+					}
+					keyvalues, err := uploads.ParseKeyValues(ctx.StringSlice("keyvalues"), ctx.String("kv-file"))
+					if err != nil {
+						return err
+					}
+					limitRate, err := config.GetLimitRateParam(ctx.String("limit-rate"))
+					if err != nil {
+						return err
+					}
+					opts := uploads.Options{
+						GroupId:   ctx.String("group"),
+						Name:      ctx.String("name"),
+						Network:   ctx.String("network"),
+						KeyValues: keyvalues,
+						LimitRate: limitRate,
+					}
+					_, err = uploads.UploadJSON(data, opts)
 					return err
 				},
 			},
@@ -139,6 +752,14 @@ func main() {
 								Aliases: []string{"t"},
 								Usage:   "Paginate through results using the pageToken",
 							},
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "Follow every page and stream the results as one JSON object per line",
+							},
+							&cli.IntFlag{
+								Name:  "max",
+								Usage: "Follow pages until this many results have been streamed as one JSON object per line",
+							},
 							&cli.StringFlag{
 								Name:    "network",
 								Aliases: []string{"net"},
@@ -150,6 +771,14 @@ func main() {
 							name := ctx.String("name")
 							token := ctx.String("token")
 							network := ctx.String("network")
+							if ctx.Int("max") < 0 {
+								return errors.New("--max cannot be negative")
+							}
+							if ctx.Bool("all") || ctx.Int("max") > 0 {
+								amount = utils.PageSize(amount, ctx.IsSet("amount"), ctx.Int("max"))
+								_, err := utils.StreamJSON(groups.AllGroups(amount, name, token, network), ctx.Int("max"))
+								return err
+							}
 							_, err := groups.ListGroups(amount, name, token, network)
 							return err
 						},
@@ -288,7 +917,7 @@ func main() {
 					{
 						Name:      "delete",
 						Aliases:   []string{"d"},
-						Usage:     "Delete a file by ID",
+						Usage:     "Delete a file by ID, or every file matching filters",
 						ArgsUsage: "[ID of file]",
 						Flags: []cli.Flag{
 							&cli.StringFlag{
@@ -296,15 +925,55 @@ func main() {
 								Aliases: []string{"net"},
 								Usage:   "Specify the network (public or private). Uses default if not specified",
 							},
+							&cli.StringFlag{
+								Name:    "group",
+								Aliases: []string{"g"},
+								Usage:   "Delete files in a group",
+							},
+							&cli.StringSliceFlag{
+								Name:    "keyvalues",
+								Aliases: []string{"kv"},
+								Usage:   "Delete files with metadata keyvalues (format: key=value)",
+							},
+							&cli.StringFlag{
+								Name:    "mime",
+								Aliases: []string{"m"},
+								Usage:   "Delete files with a mime type, wildcards like video/* are supported",
+							},
+							&cli.StringFlag{
+								Name:  "created-before",
+								Usage: "Delete files created before a date, YYYY-MM-DD or RFC3339",
+							},
+							&cli.BoolFlag{
+								Name:    "yes",
+								Aliases: []string{"y"},
+								Usage:   "Delete matching files without asking for confirmation",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "List the matching files without deleting them",
+							},
+							&cli.IntFlag{
+								Name:  "concurrency",
+								Value: 5,
+								Usage: "Number of files to delete at the same time",
+							},
 						},
 						Action: func(ctx *cli.Context) error {
 							fileId := ctx.Args().First()
 							network := ctx.String("network")
-							if fileId == "" {
-								return errors.New("no file ID provided")
+							if fileId != "" {
+								if ctx.IsSet("group") || ctx.IsSet("keyvalues") || ctx.IsSet("mime") || ctx.IsSet("created-before") {
+									return errors.New("pass either a file ID or filters, not both")
+								}
+								err := files.DeleteFile(fileId, network)
+								return err
 							}
-							err := files.DeleteFile(fileId, network)
-							return err
+							keyvalues, err := uploads.ParseKeyValues(ctx.StringSlice("keyvalues"), "")
+							if err != nil {
+								return err
+							}
+							return files.DeleteMatching(ctx.String("group"), keyvalues, ctx.String("mime"), ctx.String("created-before"), network, ctx.Bool("yes"), ctx.Bool("dry-run"), ctx.Int("concurrency"))
 						},
 					},
 					{
@@ -392,6 +1061,14 @@ func main() {
 								Aliases: []string{"t"},
 								Usage:   "Paginate through file results using the pageToken",
 							},
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "Follow every page and stream the results as one JSON object per line",
+							},
+							&cli.IntFlag{
+								Name:  "max",
+								Usage: "Follow pages until this many results have been streamed as one JSON object per line",
+							},
 							&cli.BoolFlag{
 								Name:  "cidPending",
 								Value: false,
@@ -425,10 +1102,184 @@ func main() {
 									keyvalues[parts[0]] = parts[1]
 								}
 							}
+							if ctx.Int("max") < 0 {
+								return errors.New("--max cannot be negative")
+							}
+							if ctx.Bool("all") || ctx.Int("max") > 0 {
+								amount = utils.PageSize(amount, ctx.IsSet("amount"), ctx.Int("max"))
+								_, err := utils.StreamJSON(files.AllFiles(amount, token, cidPending, name, cid, group, mime, keyvalues, network), ctx.Int("max"))
+								return err
+							}
 							_, err := files.ListFiles(amount, token, cidPending, name, cid, group, mime, keyvalues, network)
 							return err
 						},
 					},
+					{
+						Name:      "download",
+						Aliases:   []string{"dl"},
+						Usage:     "Download a file by CID or ID, or the files in a group, rebuilding the folder they were uploaded from",
+						ArgsUsage: "[cid or file id]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "group",
+								Aliases: []string{"g"},
+								Usage:   "ID of the group to download",
+							},
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Path to save the file to, or folder to download a group into. Defaults to the stored name of the file or group",
+							},
+							&cli.StringFlag{
+								Name:    "network",
+								Aliases: []string{"net"},
+								Usage:   "Specify the network (public or private). Uses default if not specified",
+							},
+							&cli.BoolFlag{
+								Name:  "decrypt",
+								Usage: "Decrypt files that were uploaded with --encrypt",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							ref := ctx.Args().First()
+							groupId := ctx.String("group")
+							output := ctx.String("output")
+							network := ctx.String("network")
+							if groupId != "" {
+								if ref != "" {
+									return errors.New("pass either a CID or file ID or --group, not both")
+								}
+								return files.DownloadGroup(groupId, output, network, ctx.Bool("decrypt"))
+							}
+							if ref == "" {
+								return errors.New("no CID, file ID or group ID provided")
+							}
+							return files.DownloadFile(ref, output, network, ctx.Bool("decrypt"))
+						},
+					},
+					{
+						Name:      "cat",
+						Usage:     "Write the content of a file to stdout",
+						ArgsUsage: "[cid or cid/path/in/folder]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "range",
+								Usage: "Only write bytes start-end, or from start to the end with start-",
+							},
+							&cli.StringFlag{
+								Name:    "network",
+								Aliases: []string{"net"},
+								Usage:   "Specify the network (public or private). Uses default if not specified",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							cid := ctx.Args().First()
+							network := ctx.String("network")
+							if cid == "" {
+								return errors.New("No CID provided")
+							}
+							return files.CatFile(cid, ctx.String("range"), network)
+						},
+					},
+				},
+			},
+			{
+				Name:      "pin",
+				Usage:     "Pin content that is already on IPFS to the public network",
+				ArgsUsage: "[cid]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "name",
+						Aliases: []string{"n"},
+						Usage:   "Name for the pinned content. Uses the CID if not specified",
+					},
+					&cli.StringFlag{
+						Name:    "group",
+						Aliases: []string{"g"},
+						Usage:   "Add the pinned content to a specific group by passing in the groupId",
+					},
+					&cli.StringSliceFlag{
+						Name:    "keyvalues",
+						Aliases: []string{"kv"},
+						Usage:   "Add metadata keyvalues to the pin (format: key=value)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "host-node",
+						Usage: "Multiaddr of a node known to have the content, to speed up finding it",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					cid := ctx.Args().First()
+					if cid == "" {
+						return errors.New("No CID provided")
+					}
+					keyvalues, err := uploads.ParseKeyValues(ctx.StringSlice("keyvalues"), "")
+					if err != nil {
+						return err
+					}
+					_, err = pins.PinByHash(cid, ctx.String("name"), ctx.String("group"), keyvalues, ctx.StringSlice("host-node"))
+					return err
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:    "queue",
+						Aliases: []string{"q"},
+						Usage:   "List pins that are queued or in progress, with their status",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "cid",
+								Usage: "Only list pin jobs for a CID",
+							},
+							&cli.StringFlag{
+								Name:    "status",
+								Aliases: []string{"s"},
+								Usage:   "Filter by status: prechecking, retrieving, expired, over_free_limit, over_max_size, invalid_object or bad_host_node",
+							},
+							&cli.StringFlag{
+								Name:  "sort",
+								Usage: "Sort by queue date, ASC or DESC",
+							},
+							&cli.StringFlag{
+								Name:    "amount",
+								Aliases: []string{"a"},
+								Usage:   "The number of pin jobs you would like to return",
+							},
+							&cli.StringFlag{
+								Name:    "offset",
+								Aliases: []string{"o"},
+								Usage:   "Offset the number of records that are returned",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							_, err := pins.ListPinJobs(ctx.String("cid"), ctx.String("status"), ctx.String("sort"), ctx.String("amount"), ctx.String("offset"))
+							return err
+						},
+					},
+					{
+						Name:      "wait",
+						Aliases:   []string{"w"},
+						Usage:     "Wait until a pin completes or fails",
+						ArgsUsage: "[cid]",
+						Flags: []cli.Flag{
+							&cli.DurationFlag{
+								Name:  "interval",
+								Value: 5 * time.Second,
+								Usage: "How often to check the pin status",
+							},
+							&cli.DurationFlag{
+								Name:  "timeout",
+								Usage: "Give up after this long, e.g. 30m. Waits until interrupted if not specified",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							cid := ctx.Args().First()
+							if cid == "" {
+								return errors.New("No CID provided")
+							}
+							_, err := pins.WaitForPin(cid, ctx.Duration("interval"), ctx.Duration("timeout"))
+							return err
+						},
+					},
 				},
 			},
 			{
@@ -653,6 +1504,14 @@ func main() {
 								Aliases: []string{"o"},
 								Usage:   "Offset the number of results to paginate",
 							},
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "Follow every page and stream the results as one JSON object per line",
+							},
+							&cli.IntFlag{
+								Name:  "max",
+								Usage: "Follow pages until this many results have been streamed as one JSON object per line",
+							},
 						},
 						Action: func(ctx *cli.Context) error {
 							name := ctx.String("name")
@@ -660,6 +1519,13 @@ func main() {
 							revoked := ctx.Bool("revoked")
 							uses := ctx.Bool("uses")
 							exhausted := ctx.Bool("exhausted")
+							if ctx.Int("max") < 0 {
+								return errors.New("--max cannot be negative")
+							}
+							if ctx.Bool("all") || ctx.Int("max") > 0 {
+								_, err := utils.StreamJSON(keys.AllKeys(name, revoked, uses, exhausted, offset), ctx.Int("max"))
+								return err
+							}
 							_, err := keys.ListKeys(name, revoked, uses, exhausted, offset)
 							return err
 						},
@@ -680,6 +1546,48 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "vectors",
+				Aliases: []string{"v"},
+				Usage:   "Search and manage vectorized private files",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "query",
+						Aliases:   []string{"q"},
+						Usage:     "Search the vectorized files in a group, returning the best matches first",
+						ArgsUsage: "[query text]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "group",
+								Aliases:  []string{"g"},
+								Usage:    "ID of the group to search",
+								Required: true,
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							text := strings.Join(ctx.Args().Slice(), " ")
+							if text == "" {
+								return errors.New("no query text provided")
+							}
+							_, err := vectors.QueryVectors(ctx.String("group"), text)
+							return err
+						},
+					},
+					{
+						Name:      "delete",
+						Aliases:   []string{"d"},
+						Usage:     "Delete the vectors for a file, leaving the file itself",
+						ArgsUsage: "[ID of file]",
+						Action: func(ctx *cli.Context) error {
+							fileId := ctx.Args().First()
+							if fileId == "" {
+								return errors.New("no file ID provided")
+							}
+							return vectors.DeleteVectors(fileId)
+						},
+					},
+				},
+			},
 			{
 				Name:    "config",
 				Aliases: []string{"cfg"},
@@ -704,12 +1612,103 @@ func main() {
 							return config.SetDefaultNetwork(network)
 						},
 					},
+					{
+						Name:      "limit-rate",
+						Usage:     "Set a default upload bandwidth limit, e.g. 5MB/s, or off to remove it",
+						ArgsUsage: "[rate]",
+						Action: func(ctx *cli.Context) error {
+							rate := ctx.Args().First()
+							if rate == "" {
+								current, err := config.GetLimitRate()
+								if err != nil {
+									return err
+								}
+								if current == "" {
+									current = "off"
+								}
+								fmt.Printf("Current upload rate limit: %s\n", current)
+								return nil
+							}
+							return config.SetLimitRate(rate)
+						},
+					},
+					{
+						Name:      "chunk-size",
+						Usage:     "Set the default chunk size for resumable uploads, e.g. 50MB, or off to use the built in default",
+						ArgsUsage: "[size]",
+						Action: func(ctx *cli.Context) error {
+							size := ctx.Args().First()
+							if size == "" {
+								current, err := config.GetSizeSetting(config.ChunkSizeSetting)
+								if err != nil {
+									return err
+								}
+								if current == "" {
+									// The built in chunk size is one byte over 50MiB
+									current = fmt.Sprintf("%d bytes", uploads.CHUNK_SIZE)
+								}
+								fmt.Printf("Current chunk size: %s\n", current)
+								return nil
+							}
+							return config.SetSizeSetting(config.ChunkSizeSetting, size)
+						},
+					},
+					{
+						Name:      "resumable-threshold",
+						Usage:     "Set the default file size above which uploads are resumable, e.g. 100MB, or off to use the built in default",
+						ArgsUsage: "[size]",
+						Action: func(ctx *cli.Context) error {
+							size := ctx.Args().First()
+							if size == "" {
+								current, err := config.GetSizeSetting(config.ResumableThresholdSetting)
+								if err != nil {
+									return err
+								}
+								if current == "" {
+									current = "100MiB"
+								}
+								fmt.Printf("Current resumable threshold: %s\n", current)
+								return nil
+							}
+							return config.SetSizeSetting(config.ResumableThresholdSetting, size)
+						},
+					},
+					{
+						Name:  "encryption",
+						Usage: "Manage the age identity used for encrypted uploads",
+						Subcommands: []*cli.Command{
+							{
+								Name:  "keygen",
+								Usage: "Create the identity used to encrypt and decrypt your files",
+								Action: func(ctx *cli.Context) error {
+									_, err := encryption.GenerateIdentity()
+									return err
+								},
+							},
+							{
+								Name:  "recipient",
+								Usage: "Show the public recipient others can encrypt files to",
+								Action: func(ctx *cli.Context) error {
+									identity, err := encryption.LoadIdentity()
+									if err != nil {
+										return err
+									}
+									fmt.Println(identity.Recipient().String())
+									return nil
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
+		if errors.Is(err, uploads.ErrDuplicate) {
+			log.Print(err)
+			os.Exit(3)
+		}
 		log.Fatal(err)
 	}
 }